@@ -0,0 +1,196 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMultipartMemory 解析 multipart 表单时保存在内存中的最大字节数
+const defaultMultipartMemory = 32 << 20
+
+var ErrBindTarget = errors.New("bind: target must be a non-nil pointer to struct")
+
+// Bind 根据请求方法和 Content-Type 选择绑定方式：
+// GET/HEAD/DELETE 读 query，application/json 读 body，其余按表单处理
+func (c *Context) Bind(obj any) error {
+	switch c.Req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return c.BindQuery(obj)
+	}
+	contentType := c.Req.Header.Get("Content-Type")
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	if strings.TrimSpace(contentType) == "application/json" {
+		return c.BindJSON(obj)
+	}
+	return c.BindForm(obj)
+}
+
+// BindJSON 使用 json 标签解码 body，再按 binding 标签校验
+func (c *Context) BindJSON(obj any) error {
+	if c.Req.Body == nil {
+		return errors.New("bind: empty request body")
+	}
+	if err := json.NewDecoder(c.Req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return validate(obj, "json")
+}
+
+// BindQuery 使用 form 标签从 URL 查询参数中取值
+func (c *Context) BindQuery(obj any) error {
+	return bindValues(obj, c.Req.URL.Query(), "form")
+}
+
+// BindForm 使用 form 标签从表单中取值，包含 query、urlencoded 和 multipart
+func (c *Context) BindForm(obj any) error {
+	if err := c.parseForm(); err != nil {
+		return err
+	}
+	return bindValues(obj, c.Req.Form, "form")
+}
+
+// BindURI 使用 uri 标签从路由参数中取值
+func (c *Context) BindURI(obj any) error {
+	values := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		values[p.Key] = []string{p.Value}
+	}
+	return bindValues(obj, values, "uri")
+}
+
+func bindValues(obj any, values map[string][]string, tagKey string) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrBindTarget
+	}
+	if err := mapStruct(v.Elem(), values, tagKey); err != nil {
+		return err
+	}
+	return validate(obj, tagKey)
+}
+
+// mapStruct 逐个字段取值，未打标签的嵌套结构体会递归处理
+func mapStruct(v reflect.Value, values map[string][]string, tagKey string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup(tagKey)
+		// 标签中逗号后面是选项，例如 form:"name,omitempty"，与 fieldName 一致
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if !ok && fv.Kind() == reflect.Struct && field.Type != timeType {
+			if err := mapStruct(fv, values, tagKey); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		vs, ok := values[name]
+		if !ok || len(vs) == 0 {
+			continue
+		}
+		if err := setField(fv, field, vs); err != nil {
+			return fmt.Errorf("bind: field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func setField(fv reflect.Value, field reflect.StructField, vs []string) error {
+	switch fv.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+		for i, s := range vs {
+			if err := setValue(slice.Index(i), field, s); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Ptr:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), field, vs[0]); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+	return setValue(fv, field, vs[0])
+}
+
+// setValue 把字符串转换成字段对应的类型，非字符串字段遇到空值保持零值
+func setValue(v reflect.Value, field reflect.StructField, s string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+		return nil
+	}
+	if s == "" {
+		return nil
+	}
+	if v.Type() == timeType {
+		layout := field.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tm, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
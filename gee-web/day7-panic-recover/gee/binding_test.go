@@ -0,0 +1,75 @@
+package gee
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindUser struct {
+	ID       int       `uri:"id"`
+	Name     string    `form:"name" json:"name" binding:"required,max=8"`
+	Email    string    `form:"email" json:"email" binding:"email"`
+	Admin    bool      `form:"admin"`
+	Tags     []string  `form:"tag" binding:"max=2"`
+	Birthday time.Time `form:"birthday" time_format:"2006-01-02"`
+}
+
+func TestBindQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?name=gee&admin=true&tag=a&tag=b&birthday=2020-01-09", nil)
+	c := NewContext(httptest.NewRecorder(), req)
+	var u bindUser
+	if err := c.Bind(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "gee" || !u.Admin || len(u.Tags) != 2 || u.Birthday.Day() != 9 {
+		t.Fatalf("unexpected bind result %+v", u)
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"geektutu-too-long","email":"bad"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	c := NewContext(httptest.NewRecorder(), req)
+	var u bindUser
+	err := c.Bind(&u)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expect 2 validation errors, got %v", err)
+	}
+	if errs[0].Field != "name" || errs[0].Rule != "max" || errs[1].Field != "email" {
+		t.Fatalf("unexpected validation errors %+v", errs)
+	}
+}
+
+func TestBindURI(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/user/0", nil))
+	c.Params = Params{{Key: "id", Value: "0"}}
+	var u struct {
+		ID int `uri:"id" binding:"required,min=1"`
+	}
+	err := c.BindURI(&u)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs[0].Rule != "required" {
+		t.Fatalf("id should be required, got %v", err)
+	}
+
+	c.Params[0].Value = "abc"
+	if err := c.BindURI(&u); err == nil || errors.As(err, &errs) {
+		t.Fatalf("expect conversion error, got %v", err)
+	}
+}
+
+func TestBindTagOptions(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?name=gee&page=", nil)
+	c := NewContext(httptest.NewRecorder(), req)
+	var q struct {
+		Name string `form:"name,omitempty" binding:"required"`
+		Page int    `form:"page,omitempty"`
+	}
+	if err := c.Bind(&q); err != nil || q.Name != "gee" {
+		t.Fatalf("options after the comma should be ignored, got %+v %v", q, err)
+	}
+}
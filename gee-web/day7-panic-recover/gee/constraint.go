@@ -0,0 +1,127 @@
+package gee
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// constraints 是 {name:constraint} 中可以直接使用的约束名，其余的按正则表达式处理
+var constraints = map[string]func(string) bool{
+	"int":   isInt,
+	"uint":  isDigits,
+	"alpha": isAlpha,
+	"uuid":  isUUID,
+}
+
+// compileConstraint 返回匹配整个路径段的函数，正则会自动加上 ^ 和 $
+func compileConstraint(constraint string) func(string) bool {
+	if constraint == "" {
+		return nil
+	}
+	if match, ok := constraints[constraint]; ok {
+		return match
+	}
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("gee: invalid constraint '%s': %v", constraint, err))
+	}
+	return re.MatchString
+}
+
+func isInt(s string) bool {
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	return isDigits(s)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID 接受 8-4-4-4-12 格式的十六进制 UUID，不区分大小写
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if c := s[i] | 0x20; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// expandOptional 把末尾的可选段展开成多条路由，例如
+// /archive/{year?:int}/{month?:int} 展开为 /archive、/archive/{year:int} 和 /archive/{year:int}/{month:int}
+func expandOptional(pattern string) []string {
+	parts := strings.Split(pattern, "/")
+	first := len(parts)
+	for i, part := range parts {
+		if required, optional := optionalSegment(part); optional {
+			parts[i] = required
+			if first == len(parts) {
+				first = i
+			}
+		} else if first < len(parts) {
+			panic(fmt.Sprintf("gee: optional segment must be at the end of path '%s'", pattern))
+		}
+	}
+	if first == len(parts) {
+		return []string{pattern}
+	}
+	patterns := make([]string, 0, len(parts)-first+1)
+	for i := first; i <= len(parts); i++ {
+		p := strings.Join(parts[:i], "/")
+		if p == "" {
+			p = "/"
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// optionalSegment 判断 part 是否为 {name?} 或 {name?:constraint}，是的话返回去掉 ? 的写法。
+// ? 必须紧跟参数名，{p:https?} 中的 ? 属于正则
+func optionalSegment(part string) (string, bool) {
+	if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+		return part, false
+	}
+	name, constraint, hasConstraint := strings.Cut(part[1:len(part)-1], ":")
+	name, ok := strings.CutSuffix(name, "?")
+	if !ok {
+		return part, false
+	}
+	if hasConstraint {
+		return "{" + name + ":" + constraint + "}", true
+	}
+	return "{" + name + "}", true
+}
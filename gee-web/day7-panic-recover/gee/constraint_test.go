@@ -0,0 +1,128 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchConstraint(t *testing.T) {
+	r := NewRouter()
+	r.AddRouter("GET", "/user/{id:int}", []HandlerFunc{nil})
+	r.AddRouter("GET", "/user/{id:uuid}", []HandlerFunc{nil, nil})
+	r.AddRouter("GET", "/user/:name", []HandlerFunc{nil, nil, nil})
+	r.AddRouter("GET", `/file/{name:[a-z]+\.txt}`, []HandlerFunc{nil})
+
+	cases := []struct {
+		path, pattern string
+		params        Params
+	}{
+		{"/user/42", "/user/{id:int}", Params{{"id", "42"}}},
+		{"/user/123e4567-e89b-12d3-a456-426614174000", "/user/{id:uuid}", Params{{"id", "123e4567-e89b-12d3-a456-426614174000"}}},
+		{"/user/abc", "/user/:name", Params{{"name", "abc"}}},
+		{"/file/notes.txt", `/file/{name:[a-z]+\.txt}`, Params{{"name", "notes.txt"}}},
+	}
+	for _, tc := range cases {
+		var params Params
+		_, pattern, err := r.Search("GET", tc.path, &params)
+		if err != nil || pattern != tc.pattern || !reflect.DeepEqual(params, tc.params) {
+			t.Fatalf("%s should match %s %v, got %s %v %v", tc.path, tc.pattern, tc.params, pattern, params, err)
+		}
+	}
+	for _, path := range []string{"/file/Notes.txt", "/file/notes.txt.bak"} {
+		if _, _, err := r.Search("GET", path, new(Params)); err != ErrNotFound {
+			t.Fatalf("%s should not match the constraint", path)
+		}
+	}
+}
+
+func TestOptionalSegment(t *testing.T) {
+	r := NewRouter()
+	r.AddRouter("GET", "/archive/{year?:int}/{month?}", []HandlerFunc{nil})
+	for path, want := range map[string]string{
+		"/archive":         "/archive",
+		"/archive/2020":    "/archive/{year:int}",
+		"/archive/2020/01": "/archive/{year:int}/{month}",
+	} {
+		if _, pattern, err := r.Search("GET", path, new(Params)); err != nil || pattern != want {
+			t.Fatalf("%s should match %s, got %s %v", path, want, pattern, err)
+		}
+	}
+	if _, _, err := r.Search("GET", "/archive/latest", new(Params)); err != ErrNotFound {
+		t.Fatal("optional segment should keep its constraint")
+	}
+
+	// 正则末尾的 ? 不表示可选段
+	r.AddRouter("GET", "/proto/{p:https?}", []HandlerFunc{nil})
+	for _, path := range []string{"/proto/http", "/proto/https"} {
+		if _, pattern, err := r.Search("GET", path, new(Params)); err != nil || pattern != "/proto/{p:https?}" {
+			t.Fatalf("%s should match the regex, got %s %v", path, pattern, err)
+		}
+	}
+	if _, _, err := r.Search("GET", "/proto", new(Params)); err != ErrNotFound {
+		t.Fatal("/proto should not match a required regex segment")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("optional segment in the middle should panic")
+		}
+	}()
+	r.AddRouter("GET", "/blog/{year?}/posts", nil)
+}
+
+func TestConstraintConflict(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"/user/{id:int}", "/user/{no:int}"},
+		{"/user/:id", "/user/{name}"},
+		{"/user/{id:[0-9}", ""},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%v should panic", tc)
+				}
+			}()
+			r := NewRouter()
+			r.AddRouter("GET", tc[0], nil)
+			if tc[1] != "" {
+				r.AddRouter("GET", tc[1], nil)
+			}
+		}()
+	}
+}
+
+func TestParamTyped(t *testing.T) {
+	r := New()
+	r.GET("/user/:id", func(c *Context) {
+		id, ok := c.ParamInt("id")
+		if !ok {
+			return
+		}
+		c.String(http.StatusOK, "%d", id+1)
+	})
+	r.GET("/order/:id", func(c *Context) {
+		if id, ok := c.ParamUUID("id"); ok {
+			c.String(http.StatusOK, id)
+		}
+	})
+	for path, want := range map[string]string{
+		"/user/41": "42",
+		"/order/123E4567-E89B-12D3-A456-426614174000": "123e4567-e89b-12d3-a456-426614174000",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Fatalf("%s: unexpected response %d %q", path, w.Code, w.Body.String())
+		}
+	}
+	for _, path := range []string{"/user/abc", "/order/42"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "path parameter id") {
+			t.Fatalf("%s should fail with 400, got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,20 +1,37 @@
 package gee
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"gee/render"
 )
 
 type Context struct {
-	Writer     http.ResponseWriter
-	Req        *http.Request
-	Path       string
-	Params     map[string]string
-	StatusCode HttpStatus
-	index      int
-	handlers   []HandlerFunc
-	engine     *Engine
+	// Writer 记录了状态码和响应大小，中间件在 Next 之后可以通过 Writer.Status() 读取
+	Writer   ResponseWriter
+	Req      *http.Request
+	Path     string
+	Params   Params
+	index    int
+	handlers []HandlerFunc
+	engine   *Engine
+	writer   responseWriter
+	// Keys 保存请求范围内的数据，例如鉴权中间件得到的用户
+	Keys map[string]any
+	// fullPath 是匹配到的路由模式，例如 /user/:id
+	fullPath string
+	// form 是已经按 UploadConfig 检查过的 multipart 表单，formErr 是检查失败的原因
+	form    *multipart.Form
+	formErr error
 }
 
 type HttpStatus int
@@ -30,37 +47,175 @@ var (
 	SUCCESS = HttpStatus(200)
 )
 
+// abortIndex 大于任何调用链的长度，Next 看到它就不再执行。index 是 int，不能沿用 int8 的上限
+const abortIndex = math.MaxInt / 2
+
+// Param 是一个路由参数，按在路径中出现的顺序保存在 Params 中
+type Param struct {
+	Key   string
+	Value string
+}
+
+type Params []Param
+
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+func (ps Params) ByName(name string) string {
+	value, _ := ps.Get(name)
+	return value
+}
+
 func NewContext(writer http.ResponseWriter, r *http.Request) *Context {
-	return &Context{Writer: writer, Req: r, Path: r.URL.Path, StatusCode: SUCCESS,
-		index: -1}
+	c := &Context{}
+	c.reset(writer, r)
+	return c
 }
 
-func (c *Context) HTML(ok int, tmplName string, arg any) {
-	c.Writer.WriteHeader(ok)
-	err := c.engine.template.Lookup(tmplName).
-		Funcs(c.engine.funcMap).ExecuteTemplate(c.Writer, tmplName, arg)
-	if err != nil {
-		return
+// reset 复用 Context 处理新的请求，Params 保留底层数组
+func (c *Context) reset(writer http.ResponseWriter, r *http.Request) {
+	c.writer.reset(writer)
+	c.Writer, c.Req, c.Path = &c.writer, r, r.URL.Path
+	c.Params = c.Params[:0]
+	c.index = -1
+	c.handlers = nil
+	c.Keys = nil
+	c.fullPath = ""
+	c.form, c.formErr = nil, nil
+}
+
+// Copy 返回可以在处理函数返回后继续使用的副本，例如交给新的 goroutine。
+// Context 会被放回池中复用，不能在处理函数之外持有原对象
+func (c *Context) Copy() *Context {
+	cp := *c
+	cp.Params = append(Params(nil), c.Params...)
+	cp.Keys = make(map[string]any, len(c.Keys))
+	for k, v := range c.Keys {
+		cp.Keys[k] = v
 	}
+	// 副本只能读取状态，不能再写响应
+	cp.writer.ResponseWriter = nil
+	cp.Writer = &cp.writer
+	cp.handlers = nil
+	cp.index = abortIndex
+	return &cp
 }
 
-func (c *Context) String(ok int, s string) {
-	c.Writer.Header().Set("Content-Type", "text/plain")
-	c.Writer.WriteHeader(ok)
-	fmt.Fprintf(c.Writer, s)
+// HTML 渲染页面或模板 name。模板先写入缓冲区，找不到模板或渲染出错时记录日志，
+// 中止调用链并返回 500，不会留下写了一半的响应。funcs 覆盖加载时声明的同名函数，只对本次渲染生效
+func (c *Context) HTML(code int, name string, data any, funcs ...template.FuncMap) {
+	if c.engine == nil || c.engine.html == nil {
+		panic("gee: HTML templates are not loaded")
+	}
+	tmpl, entry, err := c.engine.html.Lookup(name, funcs...)
+	if err == nil {
+		var buf bytes.Buffer
+		if err = tmpl.ExecuteTemplate(&buf, entry, data); err == nil {
+			c.Data(code, MIMEHTML+"; charset=utf-8", buf.Bytes())
+			return
+		}
+	}
+	log.Printf("gee: render %s: %v", name, err)
+	c.Abort()
+	c.Problem(Problem{Status: http.StatusInternalServerError})
 }
 
-func (c *Context) JSON(ok int, i map[string]interface{}) {
-	c.Writer.Header().Set("Content-Type", "application/json")
-	c.Writer.WriteHeader(ok)
-	marshal, err := json.Marshal(i)
-	if err != nil {
+// Render 设置状态码并交给渲染器写出，code 为负数时由渲染器自己写状态码
+func (c *Context) Render(code int, r render.Render) {
+	r.WriteContentType(c.Writer)
+	if code > 0 {
+		c.Writer.WriteHeader(code)
+	}
+	if err := r.Render(c.Writer); err != nil {
 		panic(err)
 	}
-	c.Writer.Write(marshal)
 }
 
+func (c *Context) String(ok int, format string, values ...any) {
+	c.Render(ok, render.Text{Format: format, Data: values})
+}
+
+func (c *Context) JSON(ok int, obj any) {
+	c.Render(ok, render.JSON{Data: obj})
+}
+
+func (c *Context) IndentedJSON(ok int, obj any) {
+	c.Render(ok, render.IndentedJSON{Data: obj})
+}
+
+func (c *Context) XML(ok int, obj any) {
+	c.Render(ok, render.XML{Data: obj})
+}
+
+// ProtoBuf 写出已经编码好的 protobuf 消息
+func (c *Context) ProtoBuf(ok int, data []byte) {
+	c.Render(ok, render.ProtoBuf{Data: data})
+}
+
+func (c *Context) Data(ok int, contentType string, data []byte) {
+	c.Render(ok, render.Data{ContentType: contentType, Data: data})
+}
+
+func (c *Context) Redirect(code int, location string) {
+	c.Render(-1, render.Redirect{Code: code, Request: c.Req, Location: location})
+}
+
+func (c *Context) File(filepath string) {
+	c.Render(-1, render.File{Path: filepath, Request: c.Req})
+}
+
+// FileAttachment 以附件形式下载文件，浏览器保存为 filename
+func (c *Context) FileAttachment(filepath, filename string) {
+	c.Render(-1, render.File{Path: filepath, Name: filename, Request: c.Req})
+}
+
+// Set 在 Context 中保存一个值，Context 不是并发安全的
+func (c *Context) Set(key string, value any) {
+	if c.Keys == nil {
+		c.Keys = make(map[string]any)
+	}
+	c.Keys[key] = value
+}
+
+func (c *Context) Get(key string) (value any, ok bool) {
+	value, ok = c.Keys[key]
+	return
+}
+
+// MustGet 取不到值时 panic
+func (c *Context) MustGet(key string) any {
+	if value, ok := c.Get(key); ok {
+		return value
+	}
+	panic("gee: key \"" + key + "\" does not exist")
+}
+
+func (c *Context) GetString(key string) string {
+	s, _ := Value[string](c, key)
+	return s
+}
+
+// Value 按类型取出 Context 中的值，不存在或类型不符时 ok 为 false
+func Value[T any](c *Context, key string) (value T, ok bool) {
+	v, exists := c.Get(key)
+	if !exists {
+		return value, false
+	}
+	value, ok = v.(T)
+	return
+}
+
+// PostForm 返回表单或查询参数中的值，multipart 表单按 UploadConfig 检查，检查失败时返回空字符串
 func (c *Context) PostForm(s string) string {
+	if err := c.parseForm(); err != nil {
+		return ""
+	}
 	return c.Req.FormValue(s)
 }
 
@@ -68,19 +223,107 @@ func (c *Context) Query(s string) string {
 	return c.Req.URL.Query().Get(s)
 }
 
+// FullPath 返回匹配到的路由模式，未匹配到路由时为空字符串
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
+// ClientIP 返回客户端 IP。只有直接连接来自可信代理时才读取 X-Forwarded-For 和 X-Real-IP，
+// 可信代理通过 Engine.SetTrustedProxies 设置
+func (c *Context) ClientIP() string {
+	remote := c.remoteIP()
+	if !c.fromTrustedProxy() {
+		return remote
+	}
+	// 从右向左跳过可信代理，第一个不可信的地址就是客户端
+	if forwarded := c.GetHeader("X-Forwarded-For"); forwarded != "" {
+		ips := strings.Split(forwarded, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if i == 0 || !c.engine.isTrustedProxy(ip) {
+				return ip
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(c.GetHeader("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	return remote
+}
+
+// remoteIP 返回直接连接的对端地址
+func (c *Context) remoteIP() string {
+	remote, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(c.Req.RemoteAddr)
+	}
+	return remote
+}
+
+func (c *Context) fromTrustedProxy() bool {
+	return c.engine != nil && c.engine.isTrustedProxy(c.remoteIP())
+}
+
 func (c *Context) Param(key string) string {
-	return c.Params[key]
+	return c.Params.ByName(key)
+}
+
+// ParamInt 把路由参数解析为整数，失败时返回 400 并中止调用链，ok 为 false 时处理函数应直接返回
+func (c *Context) ParamInt(key string) (int, bool) {
+	n, err := strconv.Atoi(c.Param(key))
+	if err != nil {
+		c.Fail(http.StatusBadRequest, fmt.Sprintf("path parameter %s must be an integer", key))
+		return 0, false
+	}
+	return n, true
+}
+
+// ParamUUID 校验路由参数是 UUID 并返回小写形式，失败时与 ParamInt 相同
+func (c *Context) ParamUUID(key string) (string, bool) {
+	id := c.Param(key)
+	if !isUUID(id) {
+		c.Fail(http.StatusBadRequest, fmt.Sprintf("path parameter %s must be a UUID", key))
+		return "", false
+	}
+	return strings.ToLower(id), true
 }
 
 func (c *Context) Fail(httpStatus int, message string) {
 	// 跳过所有中间件（含接口逻辑），直接返回报错
-	c.index = len(c.handlers)
-	c.StatusCode = HttpStatus(httpStatus)
+	c.Abort()
 	c.JSON(httpStatus, H{
 		"message": message,
 	})
 }
 
+// Abort 跳过调用链中尚未执行的处理函数
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 中止调用链并只写出状态码
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.Status(code)
+}
+
+// Status 设置状态码，响应头在第一次写入或请求结束时才写出
+func (c *Context) Status(code int) {
+	c.Writer.WriteHeader(code)
+}
+
+func (c *Context) SetHeader(key, value string) {
+	c.Writer.Header().Set(key, value)
+}
+
+func (c *Context) GetHeader(key string) string {
+	return c.Req.Header.Get(key)
+}
+
 func (c *Context) Next() {
 	c.index++
 	for c.index < len(c.handlers) {
@@ -0,0 +1,45 @@
+package gee
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Cookie 返回请求中名为 name 的 cookie，值已经做过 URL 解码
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
+// SetCookie 使用安全的默认值写入 cookie：Path 为 /、HttpOnly、SameSite=Lax，
+// HTTPS 请求自动加上 Secure。maxAge 单位为秒，小于 0 表示删除。需要其他属性时使用 SetRawCookie
+func (c *Context) SetCookie(name, value string, maxAge int) {
+	c.SetRawCookie(&http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.IsTLS(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetRawCookie 原样写入 cookie，Path 为空时设为 /
+func (c *Context) SetRawCookie(cookie *http.Cookie) {
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+// IsTLS 判断客户端是否通过 HTTPS 访问，可信代理的 X-Forwarded-Proto 也会被采纳
+func (c *Context) IsTLS() bool {
+	if c.Req.TLS != nil {
+		return true
+	}
+	return c.fromTrustedProxy() && c.GetHeader("X-Forwarded-Proto") == "https"
+}
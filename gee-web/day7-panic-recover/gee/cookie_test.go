@@ -0,0 +1,31 @@
+package gee
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCookie(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		name, _ := c.Cookie("name")
+		c.SetCookie("greeting", "hello "+name, 3600)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "name", Value: "gee%21"})
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %v", cookies)
+	}
+	c := cookies[0]
+	if c.Value != "hello+gee%21" || c.Path != "/" || c.MaxAge != 3600 || !c.HttpOnly || !c.Secure ||
+		c.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie should use secure defaults, got %+v", c)
+	}
+}
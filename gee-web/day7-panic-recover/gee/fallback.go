@@ -0,0 +1,103 @@
+package gee
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NoRoute 设置路径未匹配时的处理函数，作用于该分组前缀下的请求，最长前缀优先
+func (r *RouterGroup) NoRoute(handlers ...HandlerFunc) {
+	r.noRoute = handlers
+	r.engine.addFallback(r)
+}
+
+// NoMethod 设置路径匹配但方法不支持时的处理函数，执行前 Allow 头已经写好
+func (r *RouterGroup) NoMethod(handlers ...HandlerFunc) {
+	r.noMethod = handlers
+	r.engine.addFallback(r)
+}
+
+func (engine *Engine) addFallback(group *RouterGroup) {
+	for _, g := range engine.fallbacks {
+		if g == group {
+			return
+		}
+	}
+	engine.fallbacks = append(engine.fallbacks, group)
+}
+
+// fallbackHandlers 在请求时拼接，之后再调用 Use 添加的中间件同样生效。
+// 只使用同一主机的分组设置的处理函数
+func (engine *Engine) fallbackHandlers(host *hostRouter, path string, notAllowed bool) []HandlerFunc {
+	group, handlers := engine.fallbackGroup(host, path, notAllowed)
+	return group.combineHandlers(handlers...)
+}
+
+// fallbackGroup 挑选前缀最长的设置了处理函数的分组，没有时使用根分组和默认处理函数
+func (engine *Engine) fallbackGroup(host *hostRouter, path string, notAllowed bool) (*RouterGroup, []HandlerFunc) {
+	group, handlers := engine.rootGroup(host), []HandlerFunc(nil)
+	for _, g := range engine.fallbacks {
+		h := g.noRoute
+		if notAllowed {
+			h = g.noMethod
+		}
+		if len(h) == 0 || g.host != host || !hasPathPrefix(path, g.prefix) {
+			continue
+		}
+		if handlers == nil || len(g.prefix) > len(group.prefix) {
+			group, handlers = g, h
+		}
+	}
+	if handlers == nil {
+		handlers = []HandlerFunc{defaultNoRoute}
+		if notAllowed {
+			handlers = []HandlerFunc{defaultNoMethod}
+		}
+	}
+	return group, handlers
+}
+
+// notFound 把已经匹配到路由、但处理函数找不到资源的请求（例如不存在的静态文件）交给 NoRoute。
+// from 是路由所在的分组，它和 NoRoute 分组共同祖先上的中间件已经执行过，不再重复执行
+func (c *Context) notFound(from *RouterGroup) {
+	if c.engine == nil {
+		defaultNoRoute(c)
+		return
+	}
+	group, handlers := c.engine.fallbackGroup(from.host, c.Path, false)
+	ran := make(map[*RouterGroup]bool)
+	for g := from; g != nil; g = g.parent {
+		ran[g] = true
+	}
+	var groups []*RouterGroup
+	for g := group; g != nil && !ran[g]; g = g.parent {
+		groups = append(groups, g)
+	}
+	var chain []HandlerFunc
+	for i := len(groups) - 1; i >= 0; i-- {
+		chain = append(chain, groups[i].middlewares...)
+	}
+	// 替换调用链后返回，外层 Next 的循环会从新调用链的第一个继续执行
+	c.handlers = append(chain, handlers...)
+	c.index = -1
+}
+
+// rootGroup 返回主机的根分组，host 为 nil 时是 Engine 本身
+func (engine *Engine) rootGroup(host *hostRouter) *RouterGroup {
+	if host == nil {
+		return engine.RouterGroup
+	}
+	return host.group
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+func defaultNoRoute(c *Context) {
+	c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+}
+
+func defaultNoMethod(c *Context) {
+	c.String(http.StatusMethodNotAllowed, "405 METHOD NOT ALLOWED: %s\n", c.Req.Method)
+}
@@ -1,11 +1,15 @@
 package gee
 
 import (
-	"fmt"
+	"errors"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
-	"runtime"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,134 +19,257 @@ type HandlerFunc func(ctx *Context)
 
 // Engine Engine是统一的门面
 type Engine struct {
-	routers     *Router
-	groups      []*RouterGroup
-	middlewares []HandlerFunc
-	funcMap     template.FuncMap
-	template    *template.Template
-	statics     []string
+	// 根分组，Engine 的注册方法和全局中间件都来自它
+	*RouterGroup
+	routers *Router
+	// 复用 Context，避免每个请求分配
+	pool sync.Pool
+	// 设置过 NoRoute/NoMethod 的分组
+	fallbacks []*RouterGroup
+	// 优雅退出：运行中的 Server、退出钩子和进行中的请求数
+	mu         sync.Mutex
+	servers    []*http.Server
+	onShutdown []func()
+	active     atomic.Int64
+	// 命名路由，名字到路由模式，见 Engine.URL
+	routeNames map[string]string
+	// OpenAPI 注解，key 为 "方法 路由模式"，见 Route.Summary
+	docs map[docKey]*routeDoc
+	// 按主机划分的路由，见 Host
+	hosts         map[string]*hostRouter
+	wildcardHosts []*hostRouter
+	fallbackHost  *hostRouter
+	// 可信代理的网段，见 Context.ClientIP
+	trustedProxies []netip.Prefix
+	funcMap        template.FuncMap
+	html           *HTMLTemplates
+	statics        []string
+	// 文件上传的限制，见 SetUploadConfig
+	upload UploadConfig
 }
 
 // RouterGroup 是分组代理，也有注册方法
 type RouterGroup struct {
 	prefix      string
+	parent      *RouterGroup
 	engine      *Engine
 	middlewares []HandlerFunc
+	noRoute     []HandlerFunc
+	noMethod    []HandlerFunc
+	// host 为 nil 表示注册到 Engine 本身的路由
+	host *hostRouter
 }
 
 type HttpHandlerRegistry interface {
-	GET(path string, handler HandlerFunc)
-	POST(path string, handler HandlerFunc)
+	GET(path string, handler HandlerFunc) *Route
+	POST(path string, handler HandlerFunc) *Route
+	PUT(path string, handler HandlerFunc) *Route
+	PATCH(path string, handler HandlerFunc) *Route
+	DELETE(path string, handler HandlerFunc) *Route
+	HEAD(path string, handler HandlerFunc) *Route
+	OPTIONS(path string, handler HandlerFunc) *Route
+	Any(path string, handler HandlerFunc) *Route
 }
 
 func New() *Engine {
-	return &Engine{routers: NewRouter()}
+	engine := &Engine{routers: NewRouter()}
+	engine.RouterGroup = &RouterGroup{engine: engine}
+	engine.pool.New = func() any {
+		return &Context{engine: engine, Params: make(Params, 0, engine.routers.trie.maxParams)}
+	}
+	return engine
 }
 
 func Default() *Engine {
 	engine := New()
-	engine.Use(Logger())
-	engine.Use(panicRecover())
+	engine.Use(Logger(), Recovery())
 	return engine
 }
 
-func panicRecover() HandlerFunc {
-	return func(ctx *Context) {
-		defer func() {
-			if r := recover(); r != nil {
-				buf := make([]byte, 4096)
-				n := runtime.Stack(buf, false)
-				log.Printf("Panic: %+v\n%s", r, buf[:n])
-				ctx.Fail(500, "panic")
-			}
-		}()
-		ctx.Next()
-	}
-}
-
 func (engine *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	engine.active.Add(1)
+	defer engine.active.Add(-1)
+	ctx := engine.pool.Get().(*Context)
+	ctx.reset(writer, request)
 	method, path := request.Method, request.URL.Path
-	handlerFunc, err := engine.routers.Search(method, path)
+	router, host := engine.routers, engine.matchHost(request.Host, &ctx.Params)
+	if host != nil {
+		router = host.router
+	}
+	handlers, fullPath, err := router.Search(method, path, &ctx.Params)
+	ctx.fullPath = fullPath
 	if err != nil {
-		fmt.Fprintf(writer, err.Error())
-		return
+		handlers = engine.errorHandlers(writer, host, method, path, err)
 	}
-	ctx := NewContext(writer, request)
-	ctx.engine = engine
-	// 处理中间件
-	pushMiddleware(ctx, engine, handlerFunc)
+	ctx.handlers = handlers
 	// 启动
 	ctx.Next()
+	// 只设置了状态码、没有写响应体的处理函数也需要写出响应头
+	ctx.writer.WriteHeaderNow()
+	engine.pool.Put(ctx)
 }
 
-func pushMiddleware(ctx *Context, engine *Engine, handlerFunc HandlerFunc) {
-	assembleMiddlewares := make([]HandlerFunc, 0)
-	// 加入组中间件
-	for _, group := range engine.groups {
-		assembleMiddlewares = append(assembleMiddlewares, group.middlewares...)
+// errorHandlers 为未匹配的请求挑选调用链，放在独立函数中避免 ServeHTTP 的变量逃逸
+func (engine *Engine) errorHandlers(writer http.ResponseWriter, host *hostRouter, method, path string, err error) []HandlerFunc {
+	var notAllowed *MethodNotAllowedError
+	if !errors.As(err, &notAllowed) {
+		return engine.fallbackHandlers(host, path, false)
+	}
+	if method == http.MethodOptions {
+		// 未注册 OPTIONS 时自动应答，只经过全局中间件和主机分组的中间件
+		return engine.rootGroup(host).combineHandlers(optionsHandler(notAllowed.Allow))
 	}
-	// 加入global中间件
-	assembleMiddlewares = append(assembleMiddlewares, engine.middlewares...)
-	assembleMiddlewares = append(assembleMiddlewares, handlerFunc)
-	ctx.handlers = assembleMiddlewares
+	writer.Header().Set("Allow", strings.Join(notAllowed.Allow, ", "))
+	return engine.fallbackHandlers(host, path, true)
+}
+
+func optionsHandler(allow []string) HandlerFunc {
+	return func(c *Context) {
+		c.Writer.Header().Set("Allow", strings.Join(allow, ", "))
+		c.Writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Group 基于当前分组创建子分组，前缀和中间件都会继承
+func (r *RouterGroup) Group(prefix string) *RouterGroup {
+	return &RouterGroup{prefix: r.prefix + prefix, parent: r, engine: r.engine, host: r.host}
+}
+
+// Use 只对之后在该分组（含子分组）下注册的路由生效
+func (r *RouterGroup) Use(middlewares ...HandlerFunc) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// combineHandlers 从根分组到当前分组依次拼接中间件，最后是处理函数
+func (r *RouterGroup) combineHandlers(handler ...HandlerFunc) []HandlerFunc {
+	var groups []*RouterGroup
+	for g := r; g != nil; g = g.parent {
+		groups = append(groups, g)
+	}
+	handlers := make([]HandlerFunc, 0)
+	for i := len(groups) - 1; i >= 0; i-- {
+		handlers = append(handlers, groups[i].middlewares...)
+	}
+	return append(handlers, handler...)
 }
 
 // 注册时，实际注册的handlerFunc要包装middlewares
-func (engine *Engine) addRoute(method, path string, handlerFunc HandlerFunc) {
-	engine.routers.AddRouter(method, path, handlerFunc)
+func (r *RouterGroup) addRoute(method, path string, handler HandlerFunc) *Route {
+	router := r.engine.routers
+	if r.host != nil {
+		router = r.host.router
+	}
+	router.AddRouter(method, r.prefix+path, r.combineHandlers(handler))
+	return &Route{engine: r.engine, host: r.host, methods: []string{method}, pattern: r.prefix + path}
+}
+
+func (r *RouterGroup) GET(path string, handler HandlerFunc) *Route {
+	return r.addRoute("GET", path, handler)
+}
+
+func (r *RouterGroup) POST(path string, handler HandlerFunc) *Route {
+	return r.addRoute("POST", path, handler)
 }
 
-func (engine *Engine) GET(path string, handlerFunc HandlerFunc) {
-	engine.addRoute("GET", path, handlerFunc)
+func (r *RouterGroup) PUT(path string, handler HandlerFunc) *Route {
+	return r.addRoute("PUT", path, handler)
 }
 
-func (engine *Engine) POST(path string, handlerFunc HandlerFunc) {
-	engine.addRoute("POST", path, handlerFunc)
+func (r *RouterGroup) PATCH(path string, handler HandlerFunc) *Route {
+	return r.addRoute("PATCH", path, handler)
 }
 
-func (engine *Engine) Run(addr string) error {
-	err := http.ListenAndServe(addr, engine)
-	return err
+func (r *RouterGroup) DELETE(path string, handler HandlerFunc) *Route {
+	return r.addRoute("DELETE", path, handler)
 }
 
-func (engine *Engine) Group(prefix string) *RouterGroup {
-	r := &RouterGroup{prefix: prefix, engine: engine}
-	engine.groups = append(engine.groups, r)
-	return r
+func (r *RouterGroup) HEAD(path string, handler HandlerFunc) *Route {
+	return r.addRoute("HEAD", path, handler)
 }
 
-func (r *RouterGroup) GET(path string, handler HandlerFunc) {
-	r.engine.GET(r.prefix+path, handler)
+func (r *RouterGroup) OPTIONS(path string, handler HandlerFunc) *Route {
+	return r.addRoute("OPTIONS", path, handler)
 }
 
-func (r *RouterGroup) POST(path string, handler HandlerFunc) {
-	r.engine.POST(r.prefix+path, handler)
+// Any 为所有常用方法注册同一个处理函数
+func (r *RouterGroup) Any(path string, handler HandlerFunc) *Route {
+	for _, method := range anyMethods {
+		r.addRoute(method, path, handler)
+	}
+	return &Route{engine: r.engine, host: r.host, methods: anyMethods, pattern: r.prefix + path}
+}
+
+// SetTrustedProxies 设置可信代理的 IP 或 CIDR，来自它们的请求才会读取 X-Forwarded-For
+func (engine *Engine) SetTrustedProxies(proxies []string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	engine.trustedProxies = prefixes
+	return nil
 }
 
-func (engine *Engine) Use(middleware HandlerFunc) {
-	engine.middlewares = append(engine.middlewares, middleware)
+func (engine *Engine) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range engine.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
 }
 
 func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 	engine.funcMap = funcMap
 }
 
+// LoadHTMLGlob 加载匹配 pattern 的模板，按模板名渲染，解析出错时 panic
 func (engine *Engine) LoadHTMLGlob(pattern string) {
-	tmpl, err := template.New("template").Funcs(engine.funcMap).ParseGlob(pattern)
-	if err != nil {
-		return
+	if err := engine.LoadHTML(HTMLConfig{Partials: []string{pattern}}); err != nil {
+		panic(err)
 	}
-	engine.template = tmpl
 }
 
-func (engine *Engine) Static(httpPath, realPath string) {
-	engine.GET(httpPath+"/*filename", func(ctx *Context) {
-		http.ServeFile(ctx.Writer, ctx.Req, realPath+"/"+ctx.Param("filename"))
-	})
+// LoadHTMLFS 从 fsys（例如 embed.FS）加载模板，解析出错时 panic
+func (engine *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	if err := engine.LoadHTML(HTMLConfig{FS: fsys, Partials: patterns}); err != nil {
+		panic(err)
+	}
 }
 
-func (r *RouterGroup) Use(middleware HandlerFunc) {
-	r.middlewares = append(r.middlewares, middleware)
+// LoadHTML 按 config 加载布局、局部模板和页面，需要在 SetFuncMap 之后调用。
+// 模板中可以使用 url 函数生成命名路由的地址，参数同 Engine.URL
+func (engine *Engine) LoadHTML(config HTMLConfig) error {
+	if config.FuncMap == nil {
+		config.FuncMap = engine.funcMap
+	}
+	funcs := template.FuncMap{"url": engine.URL}
+	for name, f := range config.FuncMap {
+		funcs[name] = f
+	}
+	config.FuncMap = funcs
+	html, err := NewHTMLTemplates(config)
+	if err != nil {
+		return err
+	}
+	engine.html = html
+	return nil
 }
 
 // 2019/08/17 01:37:38 [200] / in 3.14µs
@@ -151,6 +278,6 @@ func Logger() HandlerFunc {
 		before := time.Now()
 		ctx.Next()
 		duration := time.Now().Sub(before)
-		log.Default().Printf("[%d] %s in %s", ctx.StatusCode, ctx.Path, duration.String())
+		log.Default().Printf("[%d] %s in %s", ctx.Writer.Status(), ctx.Path, duration.String())
 	}
 }
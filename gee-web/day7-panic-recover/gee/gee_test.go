@@ -0,0 +1,175 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNestedGroup(t *testing.T) {
+	r := New()
+	v1 := r.Group("/v1")
+	v2 := v1.Group("/v2")
+	v3 := v2.Group("/v3")
+	if v2.prefix != "/v1/v2" {
+		t.Fatal("v2 prefix should be /v1/v2")
+	}
+	if v3.prefix != "/v1/v2/v3" {
+		t.Fatal("v3 prefix should be /v1/v2/v3")
+	}
+}
+
+func TestGroupMiddleware(t *testing.T) {
+	mark := func(s string) HandlerFunc {
+		return func(c *Context) {
+			c.Writer.Write([]byte(s))
+			c.Next()
+		}
+	}
+	handler := func(c *Context) {
+		c.Writer.Write([]byte("h"))
+	}
+
+	r := New()
+	r.Use(mark("g"))
+	v1 := r.Group("/v1")
+	v1.Use(mark("a"), mark("b"))
+	v2 := v1.Group("/v2")
+	v2.Use(mark("c"))
+	admin := r.Group("/admin")
+	admin.Use(mark("x"))
+
+	r.GET("/v1x", handler)
+	v1.GET("/hello", handler)
+	v2.GET("/hello", handler)
+	admin.GET("/hello", handler)
+
+	cases := map[string]string{
+		"/v1x":         "gh",
+		"/v1/hello":    "gabh",
+		"/v1/v2/hello": "gabch",
+		"/admin/hello": "gxh",
+	}
+	for path, want := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := w.Body.String(); got != want {
+			t.Fatalf("%s should run %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestAbortLongChain(t *testing.T) {
+	r := New()
+	ran := 0
+	for i := 0; i < 100; i++ {
+		r.Use(func(c *Context) { ran++ })
+	}
+	r.Use(func(c *Context) { c.AbortWithStatus(http.StatusForbidden) })
+	r.Use(func(c *Context) { t.Error("handler after Abort should not run") })
+	r.GET("/", func(c *Context) { t.Error("handler after Abort should not run") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if ran != 100 || w.Code != http.StatusForbidden {
+		t.Fatalf("expect 100 handlers and 403, got %d and %d", ran, w.Code)
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	r := New()
+	r.GET("/hello", func(c *Context) {})
+	api := r.Group("/api")
+	api.NoRoute(func(c *Context) {
+		c.Problem(Problem{Status: http.StatusNotFound, Detail: "no such api"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nothing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 NOT FOUND: /nothing\n" {
+		t.Fatalf("unexpected default 404 response %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/nothing", nil))
+	want := `{"title":"Not Found","status":404,"detail":"no such api","instance":"/api/nothing"}`
+	if w.Code != http.StatusNotFound || w.Body.String() != want {
+		t.Fatalf("unexpected api 404 response %d %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != MIMEProblemJSON {
+		t.Fatalf("Content-Type should be %s, got %s", MIMEProblemJSON, ct)
+	}
+}
+
+func TestNoMethod(t *testing.T) {
+	r := New()
+	var logged int
+	r.Use(func(c *Context) {
+		c.Next()
+		logged = c.Writer.Status()
+	})
+	r.GET("/hello", func(c *Context) {})
+	r.NoMethod(func(c *Context) {
+		c.String(http.StatusMethodNotAllowed, "allow: %s", c.Writer.Header().Get("Allow"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hello", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != "allow: GET, OPTIONS" {
+		t.Fatalf("unexpected 405 response %d %q", w.Code, w.Body.String())
+	}
+	if logged != http.StatusMethodNotAllowed {
+		t.Fatal("global middleware should run for NoMethod handlers")
+	}
+}
+
+func TestCustomRecovery(t *testing.T) {
+	r := New()
+	r.Use(CustomRecovery(func(c *Context, err any) {
+		c.Problem(Problem{Detail: err.(string)})
+	}))
+	r.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	want := `{"title":"Internal Server Error","status":500,"detail":"boom","instance":"/panic"}`
+	if w.Code != http.StatusInternalServerError || w.Body.String() != want {
+		t.Fatalf("unexpected recovery response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := New()
+	var ip, fullPath string
+	r.GET("/user/:id", func(c *Context) {
+		ip, fullPath = c.ClientIP(), c.FullPath()
+	})
+	get := func(remote, forwarded string) string {
+		req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", forwarded)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return ip
+	}
+
+	if got := get("10.0.0.1:80", "1.2.3.4"); got != "10.0.0.1" {
+		t.Fatalf("X-Forwarded-For from untrusted peer should be ignored, got %s", got)
+	}
+	if fullPath != "/user/:id" {
+		t.Fatalf("FullPath should be the route pattern, got %s", fullPath)
+	}
+	if err := r.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("10.0.0.1:80", "1.2.3.4, 192.168.1.1"); got != "1.2.3.4" {
+		t.Fatalf("trusted proxies should be skipped, got %s", got)
+	}
+	if got := get("10.0.0.1:80", "6.6.6.6, 5.5.5.5, 192.168.1.1"); got != "5.5.5.5" {
+		t.Fatalf("first untrusted hop from the right should be used, got %s", got)
+	}
+	if err := r.SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("invalid proxy should return an error")
+	}
+}
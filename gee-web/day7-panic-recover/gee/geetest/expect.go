@@ -0,0 +1,137 @@
+package geetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Expect 对响应做断言，失败时用 t.Errorf 报告并继续，方法返回自身以便链式调用
+type Expect struct {
+	t   testing.TB
+	Rec *httptest.ResponseRecorder
+}
+
+func ExpectResponse(t testing.TB, rec *httptest.ResponseRecorder) *Expect {
+	return &Expect{t: t, Rec: rec}
+}
+
+func (e *Expect) Status(code int) *Expect {
+	e.t.Helper()
+	if e.Rec.Code != code {
+		e.t.Errorf("status: expect %d, got %d, body %q", code, e.Rec.Code, e.Rec.Body.String())
+	}
+	return e
+}
+
+func (e *Expect) Header(key, value string) *Expect {
+	e.t.Helper()
+	if got := e.Rec.Header().Get(key); got != value {
+		e.t.Errorf("header %s: expect %q, got %q", key, value, got)
+	}
+	return e
+}
+
+func (e *Expect) Body(body string) *Expect {
+	e.t.Helper()
+	if got := e.Rec.Body.String(); got != body {
+		e.t.Errorf("body: expect %q, got %q", body, got)
+	}
+	return e
+}
+
+func (e *Expect) BodyContains(s string) *Expect {
+	e.t.Helper()
+	if !strings.Contains(e.Rec.Body.String(), s) {
+		e.t.Errorf("body: expect to contain %q, got %q", s, e.Rec.Body.String())
+	}
+	return e
+}
+
+// Cookie 检查响应中名为 name 的 cookie 的值
+func (e *Expect) Cookie(name, value string) *Expect {
+	e.t.Helper()
+	for _, cookie := range e.Rec.Result().Cookies() {
+		if cookie.Name == name {
+			if cookie.Value != value {
+				e.t.Errorf("cookie %s: expect %q, got %q", name, value, cookie.Value)
+			}
+			return e
+		}
+	}
+	e.t.Errorf("cookie %s: not set", name)
+	return e
+}
+
+// JSON 检查响应体与 want 编码后的 JSON 等价，忽略字段顺序和空白
+func (e *Expect) JSON(want any) *Expect {
+	e.t.Helper()
+	var got any
+	if err := json.Unmarshal(e.Rec.Body.Bytes(), &got); err != nil {
+		e.t.Errorf("body is not JSON: %v", err)
+		return e
+	}
+	if !jsonEqual(got, want) {
+		e.t.Errorf("json: expect %s, got %s", mustMarshal(want), e.Rec.Body.String())
+	}
+	return e
+}
+
+// JSONPath 检查响应体中 path 处的值，path 用 . 分隔，数组用下标，例如 items.0.name
+func (e *Expect) JSONPath(path string, want any) *Expect {
+	e.t.Helper()
+	var v any
+	if err := json.Unmarshal(e.Rec.Body.Bytes(), &v); err != nil {
+		e.t.Errorf("body is not JSON: %v", err)
+		return e
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[key]; !ok {
+				e.t.Errorf("json path %s: %s not found", path, key)
+				return e
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				e.t.Errorf("json path %s: bad index %s", path, key)
+				return e
+			}
+			v = node[i]
+		default:
+			e.t.Errorf("json path %s: %s is not an object or array", path, key)
+			return e
+		}
+	}
+	if !jsonEqual(v, want) {
+		e.t.Errorf("json path %s: expect %s, got %s", path, mustMarshal(want), mustMarshal(v))
+	}
+	return e
+}
+
+// jsonEqual 比较两个值编码后的 JSON，这样 1 和 1.0、结构体和 map 可以相等
+func jsonEqual(got, want any) bool {
+	return bytes.Equal(mustMarshal(got), normalize(want))
+}
+
+// normalize 把 want 编码再解码，使结构体与解码得到的 map 编码结果一致
+func normalize(v any) []byte {
+	var out any
+	if err := json.Unmarshal(mustMarshal(v), &out); err != nil {
+		return mustMarshal(v)
+	}
+	return mustMarshal(out)
+}
+
+func mustMarshal(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return []byte(err.Error())
+	}
+	return b
+}
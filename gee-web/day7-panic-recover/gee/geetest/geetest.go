@@ -0,0 +1,147 @@
+// Package geetest 用于在测试中驱动完整的 gee.Engine，或单独测试一个 HandlerFunc。
+//
+//	geetest.New(engine).GET("/x").WithHeader("Accept", "application/json").
+//		Expect(t).Status(200).JSONPath("a.b", 1)
+package geetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gee"
+)
+
+// baseURL 是请求使用的地址，cookie 按它的域名保存
+const baseURL = "http://example.com"
+
+// Client 向 handler 发送请求，响应中的 cookie 会保存在 Jar 中并带到之后的请求里
+type Client struct {
+	handler http.Handler
+	Jar     http.CookieJar
+	// Header 会加到每一个请求上
+	Header http.Header
+}
+
+// New 返回驱动 handler 的 Client，handler 通常是 *gee.Engine
+func New(handler http.Handler) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{handler: handler, Jar: jar, Header: make(http.Header)}
+}
+
+func (c *Client) GET(path string) *Request     { return c.Request(http.MethodGet, path) }
+func (c *Client) POST(path string) *Request    { return c.Request(http.MethodPost, path) }
+func (c *Client) PUT(path string) *Request     { return c.Request(http.MethodPut, path) }
+func (c *Client) PATCH(path string) *Request   { return c.Request(http.MethodPatch, path) }
+func (c *Client) DELETE(path string) *Request  { return c.Request(http.MethodDelete, path) }
+func (c *Client) HEAD(path string) *Request    { return c.Request(http.MethodHead, path) }
+func (c *Client) OPTIONS(path string) *Request { return c.Request(http.MethodOptions, path) }
+
+func (c *Client) Request(method, path string) *Request {
+	return &Request{client: c, method: method, path: path, header: c.Header.Clone(), query: make(url.Values)}
+}
+
+// Request 是待发送的请求，With 系列方法返回自身以便链式调用
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	cookies []*http.Cookie
+	body    io.Reader
+	err     error
+}
+
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithCookie 只给这一个请求加上 cookie，不会写入 Jar
+func (r *Request) WithCookie(name, value string) *Request {
+	r.cookies = append(r.cookies, &http.Cookie{Name: name, Value: value})
+	return r
+}
+
+func (r *Request) WithBody(contentType string, body io.Reader) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+func (r *Request) WithJSON(v any) *Request {
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+	}
+	return r.WithBody("application/json", bytes.NewReader(b))
+}
+
+func (r *Request) WithForm(form url.Values) *Request {
+	return r.WithBody("application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+}
+
+// Do 发送请求并返回记录的响应
+func (r *Request) Do() (*httptest.ResponseRecorder, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	target := baseURL + r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(r.path, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+	req := httptest.NewRequest(r.method, target, r.body)
+	req.Header = r.header
+	for _, cookie := range r.client.Jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(rec, req)
+	if cookies := rec.Result().Cookies(); len(cookies) > 0 {
+		r.client.Jar.SetCookies(req.URL, cookies)
+	}
+	return rec, nil
+}
+
+// Expect 发送请求并返回对响应的断言，请求失败时测试立即结束
+func (r *Request) Expect(t testing.TB) *Expect {
+	t.Helper()
+	rec, err := r.Do()
+	if err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.path, err)
+	}
+	return ExpectResponse(t, rec)
+}
+
+// NewContext 为单独测试一个 HandlerFunc 构造 Context，params 是路由参数
+func NewContext(req *http.Request, params ...gee.Param) (*gee.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	c := gee.NewContext(rec, req)
+	c.Params = append(c.Params, params...)
+	return c, rec
+}
+
+// Call 执行 handler 并写出状态码，只设置了状态码而没有写响应体的处理函数也能被记录
+func Call(c *gee.Context, handler gee.HandlerFunc) {
+	handler(c)
+	c.Writer.WriteHeaderNow()
+}
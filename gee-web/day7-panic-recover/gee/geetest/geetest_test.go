@@ -0,0 +1,93 @@
+package geetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gee"
+)
+
+func newEngine() *gee.Engine {
+	r := gee.New()
+	r.GET("/user/:id", func(c *gee.Context) {
+		c.JSON(http.StatusOK, gee.H{
+			"id":    c.Param("id"),
+			"lang":  c.Query("lang"),
+			"agent": c.GetHeader("User-Agent"),
+			"tags":  []gee.H{{"name": "go"}},
+		})
+	})
+	r.POST("/login", func(c *gee.Context) {
+		c.SetCookie("user", c.PostForm("name"), 0)
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/me", func(c *gee.Context) {
+		user, err := c.Cookie("user")
+		if err != nil {
+			c.Fail(http.StatusUnauthorized, "login first")
+			return
+		}
+		c.String(http.StatusOK, "hello %s", user)
+	})
+	return r
+}
+
+func TestClient(t *testing.T) {
+	New(newEngine()).GET("/user/1").WithQuery("lang", "go").WithHeader("User-Agent", "geetest").
+		Expect(t).
+		Status(http.StatusOK).
+		Header("Content-Type", "application/json; charset=utf-8").
+		JSONPath("id", "1").
+		JSONPath("lang", "go").
+		JSONPath("agent", "geetest").
+		JSONPath("tags.0.name", "go").
+		JSON(map[string]any{"id": "1", "lang": "go", "agent": "geetest", "tags": []gee.H{{"name": "go"}}})
+}
+
+func TestCookieJar(t *testing.T) {
+	client := New(newEngine())
+	client.GET("/me").Expect(t).Status(http.StatusUnauthorized)
+	client.POST("/login").WithForm(url.Values{"name": {"gee"}}).
+		Expect(t).Status(http.StatusNoContent).Cookie("user", "gee")
+	client.GET("/me").Expect(t).Status(http.StatusOK).Body("hello gee")
+	New(newEngine()).GET("/me").WithCookie("user", "tutu").Expect(t).Body("hello tutu")
+}
+
+func TestExpectFailures(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.WriteHeader(http.StatusOK)
+	rec.WriteString(`{"a":{"b":[1,2]}}`)
+	spy := &spyT{TB: t}
+	ExpectResponse(spy, rec).
+		Status(http.StatusCreated).
+		JSONPath("a.b.1", 2).
+		JSONPath("a.b.2", 3).
+		JSONPath("a.c", 1).
+		Cookie("user", "gee")
+	if spy.errors != 4 {
+		t.Fatalf("expect 4 failures, got %d", spy.errors)
+	}
+}
+
+func TestCall(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/user/7", nil)
+	c, rec := NewContext(req, gee.Param{Key: "id", Value: "7"})
+	Call(c, func(c *gee.Context) {
+		if id, ok := c.ParamInt("id"); ok && id == 7 {
+			c.Status(http.StatusNoContent)
+		}
+	})
+	ExpectResponse(t, rec).Status(http.StatusNoContent).Body("")
+}
+
+// spyT 记录失败次数而不让测试失败
+type spyT struct {
+	testing.TB
+	errors int
+}
+
+func (s *spyT) Errorf(format string, args ...any) {
+	s.errors++
+}
@@ -1,3 +1,7 @@
 module gee
 
-go 1.19
+go 1.21
+
+require geecache v0.0.0
+
+replace geecache => ../../../gee-cache/day6-single-flight/geecache
@@ -0,0 +1,91 @@
+package gee
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// hostRouter 是一个主机独立的路由树和根分组
+type hostRouter struct {
+	pattern string
+	// suffix 非空表示通配主机，例如 *.tenant.example.com 的 .tenant.example.com
+	suffix string
+	// param 是通配的子域名在 Params 中的名字
+	param  string
+	router *Router
+	group  *RouterGroup
+}
+
+// Host 返回只处理发往 pattern 的请求的分组，每个主机有独立的路由树，多次调用返回同一个分组。
+// pattern 可以是 api.example.com，也可以通配一级子域名：*.tenant.example.com 或
+// {tenant}.example.com，子域名分别用 c.Param("subdomain") 和 c.Param("tenant") 读取。
+// 全局中间件对所有主机生效，没有匹配的主机时使用 Engine 本身注册的路由，见 SetFallbackHost
+func (engine *Engine) Host(pattern string) *RouterGroup {
+	return engine.hostRouter(pattern).group
+}
+
+// SetFallbackHost 让没有匹配任何主机的请求使用 pattern 的路由，而不是 Engine 本身注册的路由
+func (engine *Engine) SetFallbackHost(pattern string) {
+	engine.fallbackHost = engine.hostRouter(pattern)
+}
+
+func (engine *Engine) hostRouter(pattern string) *hostRouter {
+	pattern = normalizeHost(pattern)
+	if h, ok := engine.hosts[pattern]; ok {
+		return h
+	}
+	h := &hostRouter{pattern: pattern, router: NewRouter()}
+	h.group = &RouterGroup{parent: engine.RouterGroup, engine: engine, host: h}
+	if engine.hosts == nil {
+		engine.hosts = make(map[string]*hostRouter)
+	}
+	engine.hosts[pattern] = h
+
+	label, suffix, _ := strings.Cut(pattern, ".")
+	switch {
+	case label == "*":
+		h.param = "subdomain"
+	case strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}"):
+		h.param = label[1 : len(label)-1]
+	default:
+		return h
+	}
+	if h.param == "" || suffix == "" || strings.ContainsAny(suffix, "*{}") {
+		panic(fmt.Sprintf("gee: invalid host pattern '%s'", pattern))
+	}
+	h.suffix = "." + suffix
+	engine.wildcardHosts = append(engine.wildcardHosts, h)
+	// 更具体（后缀更长）的通配主机优先
+	sort.SliceStable(engine.wildcardHosts, func(i, j int) bool {
+		return len(engine.wildcardHosts[i].suffix) > len(engine.wildcardHosts[j].suffix)
+	})
+	return h
+}
+
+// matchHost 返回处理 host 的路由，通配的子域名追加到 params 中。返回 nil 表示使用 Engine 本身的路由
+func (engine *Engine) matchHost(host string, params *Params) *hostRouter {
+	if len(engine.hosts) == 0 {
+		return nil
+	}
+	host = normalizeHost(host)
+	if h, ok := engine.hosts[host]; ok && h.suffix == "" {
+		return h
+	}
+	for _, h := range engine.wildcardHosts {
+		if sub, ok := strings.CutSuffix(host, h.suffix); ok && sub != "" && !strings.Contains(sub, ".") {
+			*params = append(*params, Param{Key: h.param, Value: sub})
+			return h
+		}
+	}
+	return engine.fallbackHost
+}
+
+// normalizeHost 去掉端口和末尾的点并转为小写，已经是小写时不分配内存
+func normalizeHost(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), ".")
+	return strings.ToLower(host)
+}
@@ -0,0 +1,88 @@
+package gee
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func newHostEngine() *Engine {
+	r := New()
+	r.Use(func(c *Context) {
+		c.SetHeader("X-Global", "1")
+		c.Next()
+	})
+	r.GET("/", func(c *Context) { c.String(200, "default") })
+	api := r.Host("api.example.com")
+	api.GET("/", func(c *Context) { c.String(200, "api") })
+	api.GET("/user/:id", func(c *Context) { c.String(200, "api user %s", c.Param("id")) })
+	api.NoRoute(func(c *Context) { c.String(404, "api 404") })
+	r.Host("Admin.Example.com").GET("/", func(c *Context) { c.String(200, "admin") })
+	r.Host("*.tenant.example.com").GET("/", func(c *Context) { c.String(200, "tenant %s", c.Param("subdomain")) })
+	r.Host("{shop}.shops.example.com").GET("/item/:id", func(c *Context) {
+		c.String(200, "shop %s item %s", c.Param("shop"), c.Param("id"))
+	})
+	r.Host("*.eu.tenant.example.com").GET("/", func(c *Context) { c.String(200, "eu %s", c.Param("subdomain")) })
+	return r
+}
+
+func TestHostRouting(t *testing.T) {
+	r := newHostEngine()
+	tests := []struct {
+		host, path string
+		code       int
+		body       string
+	}{
+		{"api.example.com", "/", 200, "api"},
+		{"API.example.com:8080", "/user/7", 200, "api user 7"},
+		{"api.example.com", "/missing", 404, "api 404"},
+		{"admin.example.com.", "/", 200, "admin"},
+		{"acme.tenant.example.com", "/", 200, "tenant acme"},
+		{"acme.eu.tenant.example.com", "/", 200, "eu acme"},
+		{"a.b.tenant.example.com", "/", 200, "default"},
+		{"tenant.example.com", "/", 200, "default"},
+		{"books.shops.example.com", "/item/3", 200, "shop books item 3"},
+		{"other.com", "/", 200, "default"},
+		{"other.com", "/user/7", 404, "404 NOT FOUND: /user/7\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("X-Global") != "1" {
+			t.Fatalf("%s%s: expect %d %q, got %d %q", tt.host, tt.path, tt.code, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestFallbackHost(t *testing.T) {
+	r := newHostEngine()
+	r.SetFallbackHost("api.example.com")
+	req := httptest.NewRequest("GET", "/user/1", nil)
+	req.Host = "unknown.example.org"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "api user 1" {
+		t.Fatalf("unknown hosts should use the fallback host, got %q", w.Body.String())
+	}
+}
+
+func TestHostRoutes(t *testing.T) {
+	r := newHostEngine()
+	var hosts []string
+	for _, route := range r.Routes() {
+		hosts = append(hosts, route.Host+route.Path)
+	}
+	if len(hosts) != 7 || hosts[0] != "/" || hosts[1] != "*.eu.tenant.example.com/" {
+		t.Fatalf("unexpected routes %v", hosts)
+	}
+	if r.Host("api.example.com") != r.Host("API.example.com") {
+		t.Fatal("Host should return the same group for the same host")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic for invalid host pattern")
+		}
+	}()
+	r.Host("*.*.example.com")
+}
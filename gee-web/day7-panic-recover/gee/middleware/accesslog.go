@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gee"
+)
+
+// AccessLogFormat 是 AccessLog 在未指定 Logger 时的输出格式
+type AccessLogFormat int
+
+const (
+	FormatJSON AccessLogFormat = iota
+	FormatText
+	// FormatCombined 是 Apache combined 日志格式
+	FormatCombined
+)
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	// Logger 不为空时忽略 Output 和 Format
+	Logger *slog.Logger
+	// Output 默认为 os.Stdout
+	Output io.Writer
+	Format AccessLogFormat
+	// SkipPaths 中的路径不记录，Skip 返回 true 的请求也不记录
+	SkipPaths []string
+	Skip      func(c *gee.Context) bool
+	// SampleRate 在 (0, 1) 之间时按比例采样，其余值记录全部请求。4xx 和 5xx 总是记录
+	SampleRate float64
+	// Now 用于测试，默认为 time.Now
+	Now func() time.Time
+}
+
+// AccessLog 记录结构化的访问日志：method、route、path、params、status、bytes、latency、
+// client_ip、request_id、user_agent 等。5xx 使用 Error 级别，4xx 使用 Warn，其余为 Info
+func AccessLog(config AccessLogConfig) gee.HandlerFunc {
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.Logger == nil {
+		config.Logger = slog.New(newAccessLogHandler(config.Output, config.Format))
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+	return func(c *gee.Context) {
+		if skip[c.Path] || config.Skip != nil && config.Skip(c) {
+			c.Next()
+			return
+		}
+		start := config.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusBadRequest && config.SampleRate > 0 && config.SampleRate < 1 &&
+			rand.Float64() >= config.SampleRate {
+			return
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		ctx := c.Req.Context()
+		handler := config.Logger.Handler()
+		if !handler.Enabled(ctx, level) {
+			return
+		}
+		// 记录的时间是请求开始的时间
+		record := slog.NewRecord(start, level, "access", 0)
+		record.AddAttrs(
+			slog.String("method", c.Req.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Path),
+		)
+		if len(c.Params) > 0 {
+			params := make([]any, 0, len(c.Params))
+			for _, p := range c.Params {
+				params = append(params, slog.String(p.Key, p.Value))
+			}
+			record.AddAttrs(slog.Group("params", params...))
+		}
+		record.AddAttrs(
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Duration("latency", config.Now().Sub(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("request_id", GetRequestID(c)),
+			slog.String("user_agent", c.Req.UserAgent()),
+		)
+		// 以下字段只用于 combined 格式
+		if config.Format == FormatCombined {
+			user, _ := c.Get(PrincipalKey)
+			username, _ := user.(string)
+			record.AddAttrs(
+				slog.String("user", username),
+				slog.String("uri", c.Req.RequestURI),
+				slog.String("proto", c.Req.Proto),
+				slog.String("referer", c.Req.Referer()),
+			)
+		}
+		handler.Handle(ctx, record)
+	}
+}
+
+func newAccessLogHandler(w io.Writer, format AccessLogFormat) slog.Handler {
+	switch format {
+	case FormatText:
+		return slog.NewTextHandler(w, nil)
+	case FormatCombined:
+		return &combinedHandler{w: w, mu: new(sync.Mutex)}
+	}
+	return slog.NewJSONHandler(w, nil)
+}
+
+// combinedHandler 把访问日志记录格式化为 Apache combined 格式：
+// %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"
+type combinedHandler struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (h *combinedHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *combinedHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *combinedHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *combinedHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(map[string]slog.Value, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields[a.Key] = a.Value
+		return true
+	})
+	str := func(key string) string {
+		if v, ok := fields[key]; ok && v.String() != "" {
+			return v.String()
+		}
+		return "-"
+	}
+	size := "-"
+	if v, ok := fields["bytes"]; ok && v.Int64() > 0 {
+		size = strconv.FormatInt(v.Int64(), 10)
+	}
+	uri := str("uri")
+	if uri == "-" {
+		uri = str("path")
+	}
+	line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s %q %q\n",
+		str("client_ip"), str("user"), r.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		str("method"), uri, str("proto"), fields["status"].Int64(), size, str("referer"), str("user_agent"))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line)
+	return err
+}
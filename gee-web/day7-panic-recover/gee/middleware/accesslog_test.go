@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gee"
+)
+
+func TestAccessLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := gee.New()
+	r.Use(RequestID(), AccessLog(AccessLogConfig{Output: &buf, SkipPaths: []string{"/healthz"}}))
+	r.GET("/user/:id", func(c *gee.Context) {
+		c.String(http.StatusCreated, "hello")
+	})
+	r.GET("/healthz", func(c *gee.Context) {})
+
+	req := httptest.NewRequest("GET", "/user/42", nil)
+	req.Header.Set("User-Agent", "gee-test")
+	req.Header.Set(RequestIDHeader, "abc")
+	serve(r, req)
+	serve(r, httptest.NewRequest("GET", "/healthz", nil))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level": "INFO", "method": "GET", "route": "/user/:id", "path": "/user/42",
+		"status": 201.0, "bytes": 5.0, "client_ip": "192.0.2.1", "request_id": "abc", "user_agent": "gee-test",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Fatalf("%s should be %v, got %v", k, v, entry[k])
+		}
+	}
+	if params, _ := entry["params"].(map[string]any); params["id"] != "42" {
+		t.Fatalf("params should contain id, got %v", entry["params"])
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	r := gee.New()
+	r.Use(
+		AccessLog(AccessLogConfig{Output: &buf, Format: FormatCombined, Now: func() time.Time { return start }}),
+		BasicAuth("", Accounts(map[string]string{"frank": "secret"})),
+	)
+	r.GET("/apache_pb.gif", func(c *gee.Context) {
+		c.Data(http.StatusOK, "image/gif", make([]byte, 2326))
+	})
+
+	req := httptest.NewRequest("GET", "/apache_pb.gif", nil)
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://www.example.com/start.html")
+	req.Header.Set("User-Agent", "Mozilla/4.08")
+	serve(r, req)
+	want := `192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.1" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"` + "\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected combined log\n got %q\nwant %q", buf.String(), want)
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	r := gee.New()
+	r.Use(AccessLog(AccessLogConfig{Output: &buf, Format: FormatText, SampleRate: 1e-9}))
+	r.GET("/ok", func(c *gee.Context) {})
+	r.GET("/fail", func(c *gee.Context) {
+		c.Fail(http.StatusInternalServerError, "boom")
+	})
+
+	for i := 0; i < 10; i++ {
+		serve(r, httptest.NewRequest("GET", "/ok", nil))
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("successful requests should be sampled out, got %q", buf.String())
+	}
+	serve(r, httptest.NewRequest("GET", "/fail", nil))
+	if line := buf.String(); !strings.Contains(line, "level=ERROR") || !strings.Contains(line, "status=500") {
+		t.Fatalf("errors should always be logged, got %q", line)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"gee"
+)
+
+// PrincipalKey 是鉴权中间件在 Context 中保存已认证主体的 key：
+// BasicAuth 保存用户名，JWT 保存 Claims，APIKey 保存校验函数返回的主体
+const PrincipalKey = "gee.principal"
+
+// BasicAuth 校验 HTTP Basic 认证，check 返回 true 时把用户名保存到 PrincipalKey
+func BasicAuth(realm string, check func(username, password string) bool) gee.HandlerFunc {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+	return func(c *gee.Context) {
+		username, password, ok := c.Req.BasicAuth()
+		if !ok || !check(username, password) {
+			c.SetHeader("WWW-Authenticate", challenge)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set(PrincipalKey, username)
+		c.Next()
+	}
+}
+
+// Accounts 根据用户名和密码表生成校验函数，密码比较耗时与内容无关
+func Accounts(accounts map[string]string) func(username, password string) bool {
+	return func(username, password string) bool {
+		expected, ok := accounts[username]
+		if !ok {
+			// 用户不存在时也做一次比较，避免通过耗时判断用户名
+			subtle.ConstantTimeCompare([]byte(password), []byte(password))
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+	}
+}
+
+// APIKeyConfig 配置 API key 的来源和校验方式，Header 为空时使用 X-API-Key
+type APIKeyConfig struct {
+	Header string
+	// Query 不为空时，请求头中没有 key 会再从该查询参数中读取
+	Query string
+	// Validator 返回 key 对应的主体，ok 为 false 表示 key 无效
+	Validator func(key string) (principal any, ok bool)
+}
+
+func APIKey(config APIKeyConfig) gee.HandlerFunc {
+	if config.Header == "" {
+		config.Header = "X-API-Key"
+	}
+	return func(c *gee.Context) {
+		key := c.GetHeader(config.Header)
+		if key == "" && config.Query != "" {
+			key = c.Query(config.Query)
+		}
+		if key == "" {
+			c.Fail(http.StatusUnauthorized, "missing api key")
+			return
+		}
+		principal, ok := config.Validator(key)
+		if !ok {
+			c.Fail(http.StatusUnauthorized, "invalid api key")
+			return
+		}
+		c.Set(PrincipalKey, principal)
+		c.Next()
+	}
+}
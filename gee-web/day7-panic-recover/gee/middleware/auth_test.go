@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gee"
+)
+
+func principalHandler(c *gee.Context) {
+	c.JSON(http.StatusOK, c.MustGet(PrincipalKey))
+}
+
+func TestBasicAuth(t *testing.T) {
+	r := gee.New()
+	r.Use(BasicAuth("gee", Accounts(map[string]string{"geektutu": "secret"})))
+	r.GET("/", principalHandler)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("geektutu", "secret")
+	if w := serve(r, req); w.Body.String() != `"geektutu"` {
+		t.Fatalf("unexpected principal %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("geektutu", "wrong")
+	w := serve(r, req)
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Basic realm="gee", charset="UTF-8"` {
+		t.Fatalf("wrong password should be rejected, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestJWT(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	config := JWTConfig{
+		Keys:     map[string][]byte{"2020": []byte("old"), "2021": []byte("new")},
+		Audience: "gee",
+		Now:      func() time.Time { return now },
+	}
+	r := gee.New()
+	r.Use(JWT(config))
+	r.GET("/", func(c *gee.Context) {
+		claims, _ := gee.Value[Claims](c, PrincipalKey)
+		c.String(http.StatusOK, "%v", claims["sub"])
+	})
+
+	sign := func(claims Claims, kid string, key string) string {
+		token, err := SignJWT(claims, kid, []byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := Claims{"sub": "geektutu", "aud": []string{"gee", "other"}, "exp": now.Add(time.Hour).Unix()}
+	cases := []struct {
+		token string
+		code  int
+	}{
+		{sign(valid, "2020", "old"), http.StatusOK},
+		{sign(valid, "2021", "new"), http.StatusOK},
+		{sign(valid, "2021", "old"), http.StatusUnauthorized},
+		{sign(valid, "2019", "old"), http.StatusUnauthorized},
+		{sign(Claims{"sub": "a", "aud": "gee", "exp": now.Unix() - 1}, "2021", "new"), http.StatusUnauthorized},
+		{sign(Claims{"sub": "a", "aud": "gee", "nbf": now.Unix() + 60}, "2021", "new"), http.StatusUnauthorized},
+		{sign(Claims{"sub": "a", "aud": "other"}, "2021", "new"), http.StatusUnauthorized},
+		{"eyJhbGciOiJub25lIn0.eyJzdWIiOiJhIn0.", http.StatusUnauthorized},
+	}
+	for i, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		w := serve(r, req)
+		if w.Code != tc.code {
+			t.Fatalf("case %d: expect %d, got %d %s", i, tc.code, w.Code, w.Body.String())
+		}
+		if tc.code == http.StatusOK && w.Body.String() != "geektutu" {
+			t.Fatalf("case %d: unexpected subject %s", i, w.Body.String())
+		}
+	}
+	if w := serve(r, httptest.NewRequest("GET", "/", nil)); w.Code != http.StatusUnauthorized {
+		t.Fatal("request without token should be rejected")
+	}
+
+	// 不是数字的时间声明不能被忽略
+	for _, claims := range []Claims{
+		{"sub": "a", "aud": "gee", "exp": "1"},
+		{"sub": "a", "aud": "gee", "nbf": nil},
+		{"sub": "a", "aud": "gee", "iat": true},
+	} {
+		if _, err := ParseJWT(sign(claims, "2021", "new"), config); err != ErrTokenMalformed {
+			t.Fatalf("%v: expect ErrTokenMalformed, got %v", claims, err)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	r := gee.New()
+	r.Use(APIKey(APIKeyConfig{
+		Query: "api_key",
+		Validator: func(key string) (any, bool) {
+			return "service-a", key == "k1"
+		},
+	}))
+	r.GET("/", principalHandler)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", "k1")
+	if w := serve(r, req); w.Body.String() != `"service-a"` {
+		t.Fatalf("unexpected principal %s", w.Body.String())
+	}
+	if w := serve(r, httptest.NewRequest("GET", "/?api_key=k1", nil)); w.Code != http.StatusOK {
+		t.Fatal("api key in query should be accepted")
+	}
+	if w := serve(r, httptest.NewRequest("GET", "/?api_key=k2", nil)); w.Code != http.StatusUnauthorized {
+		t.Fatal("invalid api key should be rejected")
+	}
+}
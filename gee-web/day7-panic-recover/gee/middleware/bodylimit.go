@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gee"
+)
+
+// BodyLimit 限制请求体大小，Content-Length 超出时直接返回 413，
+// 否则读取超过 n 字节时返回 *http.MaxBytesError
+func BodyLimit(n int64) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		if c.Req.ContentLength > n {
+			c.Fail(http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if c.Req.Body != nil {
+			c.Req.Body = http.MaxBytesReader(c.Writer, c.Req.Body, n)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gee"
+)
+
+// cacheTagsKey 是 CacheTags 在 Context 中保存标签的 key
+const cacheTagsKey = "gee.cache.tags"
+
+// CachedResponse 是缓存的响应
+type CachedResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Created time.Time
+	Expires time.Time
+	Tags    []string
+}
+
+// CacheStore 保存缓存的响应，Get 在没有缓存、已过期或已失效时返回 nil
+type CacheStore interface {
+	Get(key string, now time.Time) (*CachedResponse, error)
+	Set(key string, resp *CachedResponse) error
+	// InvalidateTags 让带有任意一个标签的缓存失效
+	InvalidateTags(tags ...string) error
+}
+
+type CacheConfig struct {
+	Store CacheStore
+	// TTL 是缓存的有效期，默认 1 分钟
+	TTL time.Duration
+	// Query 是参与 key 的查询参数，为 nil 时使用全部查询参数
+	Query []string
+	// Headers 是参与 key 的请求头，例如 Accept-Language。带 Cookie 或 Authorization 的请求
+	// 默认不缓存，把它们加入 Headers 后按各自的值分别缓存
+	Headers []string
+	// Tags 是每个缓存都带上的标签，处理函数可以用 CacheTags 追加
+	Tags []string
+	// Statuses 是可以缓存的状态码，默认只缓存 200
+	Statuses []int
+	// MaxBodySize 是可以缓存的响应体大小上限，默认 1MB
+	MaxBodySize int
+	// Now 用于测试，默认为 time.Now
+	Now func() time.Time
+}
+
+// Cache 缓存 GET 和 HEAD 请求的响应，key 由方法、主机、路径和选定的查询参数、请求头组成。
+// 命中时直接返回缓存并加上 Age，X-Cache 为 HIT 或 MISS。请求带 Cache-Control: no-cache 时
+// 跳过缓存重新生成，no-store 时既不读也不写，带 Cookie 或 Authorization 的请求同样不读也不写。
+// 处理函数设置了 Set-Cookie 或 Cache-Control: no-store/private 的响应不会被缓存。
+// 只缓存 Cache 之后的中间件和处理函数设置的响应头，之前的中间件（例如 CORS、RequestID）每次重新设置。
+// Compress 应该放在 Cache 之前，缓存未压缩的响应；放在之后时只有 Headers 包含 Accept-Encoding 才缓存压缩的响应
+func Cache(config CacheConfig) gee.HandlerFunc {
+	if config.Store == nil {
+		config.Store = NewLRUCacheStore(64 << 20)
+	}
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	if len(config.Statuses) == 0 {
+		config.Statuses = []int{http.StatusOK}
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return func(c *gee.Context) {
+		method := c.Req.Method
+		if method != http.MethodGet && method != http.MethodHead {
+			c.Next()
+			return
+		}
+		directives := c.GetHeader("Cache-Control")
+		if hasDirective(directives, "no-store") || privateRequest(c, config.Headers) {
+			c.SetHeader("X-Cache", "BYPASS")
+			c.Next()
+			return
+		}
+		key := cacheKey(c, config)
+		now := config.Now()
+		if !hasDirective(directives, "no-cache") {
+			resp, err := config.Store.Get(key, now)
+			if err != nil {
+				log.Printf("cache: %v", err)
+			}
+			if resp != nil {
+				serveCached(c, resp, now)
+				return
+			}
+		}
+
+		// before 是之前的中间件为这个请求设置的响应头，不能缓存
+		before := c.Writer.Header().Clone()
+		c.SetHeader("X-Cache", "MISS")
+		w := &cacheWriter{ResponseWriter: c.Writer, limit: config.MaxBodySize}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+
+		header := w.header
+		if header == nil {
+			header = w.Header()
+		}
+		if w.overflow || !cacheable(c.Writer.Status(), header, config.Statuses) {
+			return
+		}
+		if header.Get("Content-Encoding") != "" && !containsHeader(config.Headers, "Accept-Encoding") {
+			return
+		}
+		header = ownHeader(header, before)
+		tags := append([]string(nil), config.Tags...)
+		if extra, ok := gee.Value[[]string](c, cacheTagsKey); ok {
+			tags = append(tags, extra...)
+		}
+		resp := &CachedResponse{
+			Status:  c.Writer.Status(),
+			Header:  header,
+			Body:    w.body,
+			Created: now,
+			Expires: now.Add(config.TTL),
+			Tags:    tags,
+		}
+		if err := config.Store.Set(key, resp); err != nil {
+			log.Printf("cache: %v", err)
+		}
+	}
+}
+
+// CacheTags 给当前请求的缓存追加标签，例如 user:42，之后可以用 InvalidateTags 让它失效
+func CacheTags(c *gee.Context, tags ...string) {
+	existing, _ := gee.Value[[]string](c, cacheTagsKey)
+	c.Set(cacheTagsKey, append(existing, tags...))
+}
+
+func cacheKey(c *gee.Context, config CacheConfig) string {
+	var b strings.Builder
+	b.WriteString(c.Req.Method)
+	b.WriteString(" ")
+	b.WriteString(c.Req.Host)
+	b.WriteString(c.Req.URL.Path)
+	query := c.Req.URL.Query()
+	if config.Query != nil {
+		selected := url.Values{}
+		for _, name := range config.Query {
+			if values, ok := query[name]; ok {
+				selected[name] = values
+			}
+		}
+		query = selected
+	}
+	if len(query) > 0 {
+		// Encode 按参数名排序，参数顺序不同的请求共用缓存
+		b.WriteString("?")
+		b.WriteString(query.Encode())
+	}
+	for _, name := range config.Headers {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(c.Req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// privateRequest 判断请求是否带有没有加入 key 的 Cookie 或 Authorization，共享缓存不能保存这样的响应
+func privateRequest(c *gee.Context, keyHeaders []string) bool {
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if c.Req.Header.Get(name) != "" && !containsHeader(keyHeaders, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHeader(headers []string, name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownHeader 返回 header 中不是从 before 原样继承的响应头，并去掉 X-Cache 和 Age
+func ownHeader(header, before http.Header) http.Header {
+	own := make(http.Header, len(header))
+	for k, v := range header {
+		if k == "X-Cache" || k == "Age" {
+			continue
+		}
+		if prev, ok := before[k]; ok && slices.Equal(prev, v) {
+			continue
+		}
+		own[k] = append([]string(nil), v...)
+	}
+	return own
+}
+
+func serveCached(c *gee.Context, resp *CachedResponse, now time.Time) {
+	header := c.Writer.Header()
+	for k, v := range resp.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set("Age", strconv.Itoa(int(now.Sub(resp.Created)/time.Second)))
+	header.Set("X-Cache", "HIT")
+	c.Writer.WriteHeader(resp.Status)
+	if c.Req.Method != http.MethodHead {
+		c.Writer.Write(resp.Body)
+	}
+	c.Abort()
+}
+
+func cacheable(status int, header http.Header, statuses []int) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	directives := header.Get("Cache-Control")
+	if hasDirective(directives, "no-store") || hasDirective(directives, "private") {
+		return false
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDirective 判断 Cache-Control 中是否有 directive，忽略大小写和参数
+func hasDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheWriter 照常写出响应，同时保存一份响应体，超过 limit 后放弃缓存。
+// header 是第一次写响应体时的响应头，与保存的响应体对应，不含之前的中间件（例如 Compress）写出时加上的头
+type cacheWriter struct {
+	gee.ResponseWriter
+	body     []byte
+	header   http.Header
+	limit    int
+	overflow bool
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+	if !w.overflow {
+		if len(w.body)+len(b) > w.limit {
+			w.overflow, w.body = true, nil
+		} else {
+			w.body = append(w.body, b...)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
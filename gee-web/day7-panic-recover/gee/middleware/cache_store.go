@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"geecache"
+	"geecache/lru"
+)
+
+// tagVersions 记录每个标签的版本，失效时只需把版本加一，
+// 缓存条目保存写入时的版本，读取时版本不一致就视为失效
+type tagVersions struct {
+	mu       sync.Mutex
+	versions map[string]uint64
+}
+
+func (t *tagVersions) snapshot(tags []string) []uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	versions := make([]uint64, len(tags))
+	for i, tag := range tags {
+		versions[i] = t.versions[tag]
+	}
+	return versions
+}
+
+func (t *tagVersions) valid(tags []string, versions []uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, tag := range tags {
+		if t.versions[tag] != versions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *tagVersions) bump(tags []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.versions == nil {
+		t.versions = make(map[string]uint64)
+	}
+	for _, tag := range tags {
+		t.versions[tag]++
+	}
+}
+
+// LRUCacheStore 使用 geecache 的 LRU 缓存保存响应，总大小不超过 maxBytes，超出时淘汰最久未访问的响应
+type LRUCacheStore struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+	tags  tagVersions
+}
+
+type lruResponse struct {
+	resp     *CachedResponse
+	versions []uint64
+}
+
+// Len 实现 lru.Value，按响应体和响应头估算
+func (r *lruResponse) Len() int {
+	n := len(r.resp.Body) + 64
+	for k, v := range r.resp.Header {
+		n += len(k)
+		for _, s := range v {
+			n += len(s)
+		}
+	}
+	return n
+}
+
+func NewLRUCacheStore(maxBytes int64) *LRUCacheStore {
+	return &LRUCacheStore{cache: lru.New(maxBytes, nil)}
+}
+
+func (s *LRUCacheStore) Get(key string, now time.Time) (*CachedResponse, error) {
+	s.mu.Lock()
+	v, ok := s.cache.Get(key)
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	entry := v.(*lruResponse)
+	if now.After(entry.resp.Expires) || !s.tags.valid(entry.resp.Tags, entry.versions) {
+		return nil, nil
+	}
+	return entry.resp, nil
+}
+
+func (s *LRUCacheStore) Set(key string, resp *CachedResponse) error {
+	entry := &lruResponse{resp: resp, versions: s.tags.snapshot(resp.Tags)}
+	s.mu.Lock()
+	s.cache.Add(key, entry)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *LRUCacheStore) InvalidateTags(tags ...string) error {
+	s.tags.bump(tags)
+	return nil
+}
+
+// GroupCacheStore 把响应编码后保存在 geecache.Group 中。Group 只能读不能写，
+// 所以每次 Set 使用新的 key，由 Group 的 Getter 从待取走的数据中读取并放入缓存，
+// 过期和失效的响应留在 Group 中等待 LRU 淘汰。Group 只在本进程内使用，不要注册 peers
+type GroupCacheStore struct {
+	group *geecache.Group
+	mu    sync.Mutex
+	seq   uint64
+	// current 是每个 key 当前在 Group 中的 key 和过期时间
+	current map[string]groupEntry
+	// pending 是已经 Set 但还没有被 Getter 取走的数据
+	pending map[string][]byte
+	sweepAt time.Time
+	tags    tagVersions
+}
+
+type groupEntry struct {
+	key     string
+	expires time.Time
+}
+
+// groupResponse 是保存在 Group 中的内容
+type groupResponse struct {
+	Resp     *CachedResponse
+	Versions []uint64
+}
+
+var errCacheMiss = errors.New("cache: miss")
+
+// NewGroupCacheStore 创建名为 name、最多缓存 cacheBytes 字节的 geecache.Group 作为存储
+func NewGroupCacheStore(name string, cacheBytes int64) *GroupCacheStore {
+	s := &GroupCacheStore{current: make(map[string]groupEntry), pending: make(map[string][]byte)}
+	s.group = geecache.NewGroup(name, cacheBytes, geecache.GetterFunc(s.load))
+	return s
+}
+
+// load 是 Group 的 Getter，数据被取走后由 Group 缓存
+func (s *GroupCacheStore) load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.pending[key]
+	if !ok {
+		return nil, errCacheMiss
+	}
+	delete(s.pending, key)
+	return b, nil
+}
+
+func (s *GroupCacheStore) Get(key string, now time.Time) (*CachedResponse, error) {
+	s.mu.Lock()
+	entry, ok := s.current[key]
+	if ok && now.After(entry.expires) {
+		delete(s.current, key)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	view, err := s.group.Get(entry.key)
+	if err != nil {
+		// 已被 Group 淘汰
+		return nil, nil
+	}
+	var stored groupResponse
+	if err := gob.NewDecoder(bytes.NewReader(view.ByteSlice())).Decode(&stored); err != nil {
+		return nil, err
+	}
+	if !s.tags.valid(stored.Resp.Tags, stored.Versions) {
+		return nil, nil
+	}
+	return stored.Resp, nil
+}
+
+func (s *GroupCacheStore) Set(key string, resp *CachedResponse) error {
+	var buf bytes.Buffer
+	stored := groupResponse{Resp: resp, Versions: s.tags.snapshot(resp.Tags)}
+	if err := gob.NewEncoder(&buf).Encode(stored); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	now := time.Now()
+	if now.After(s.sweepAt) {
+		for k, e := range s.current {
+			if now.After(e.expires) {
+				delete(s.current, k)
+			}
+		}
+		s.sweepAt = now.Add(time.Minute)
+	}
+	s.seq++
+	groupKey := key + "#" + strconv.FormatUint(s.seq, 10)
+	s.pending[groupKey] = buf.Bytes()
+	s.current[key] = groupEntry{key: groupKey, expires: resp.Expires}
+	s.mu.Unlock()
+	// 立即读取一次，把数据从 pending 移到 Group 的缓存中
+	_, err := s.group.Get(groupKey)
+	return err
+}
+
+func (s *GroupCacheStore) InvalidateTags(tags ...string) error {
+	s.tags.bump(tags)
+	return nil
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gee"
+)
+
+func newCacheEngine(store CacheStore, now *time.Time) (*gee.Engine, *int) {
+	calls := 0
+	r := gee.New()
+	r.Use(Cache(CacheConfig{
+		Store:   store,
+		TTL:     time.Minute,
+		Query:   []string{"page"},
+		Headers: []string{"Accept-Language"},
+		Tags:    []string{"users"},
+		Now:     func() time.Time { return *now },
+	}))
+	r.GET("/users/:id", func(c *gee.Context) {
+		calls++
+		CacheTags(c, "user:"+c.Param("id"))
+		c.SetHeader("X-Calls", "x")
+		c.String(http.StatusOK, "user %s page %s", c.Param("id"), c.Query("page"))
+	})
+	r.GET("/private", func(c *gee.Context) {
+		calls++
+		c.SetHeader("Cache-Control", "private")
+		c.String(http.StatusOK, "private")
+	})
+	r.GET("/missing", func(c *gee.Context) {
+		calls++
+		c.String(http.StatusNotFound, "missing")
+	})
+	return r, &calls
+}
+
+func testCacheStore(t *testing.T, store CacheStore) {
+	now := time.Unix(1700000000, 0)
+	r, calls := newCacheEngine(store, &now)
+	get := func(target string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		return serve(r, req)
+	}
+	expect := func(w *httptest.ResponseRecorder, xCache, body string, wantCalls int) {
+		t.Helper()
+		if w.Header().Get("X-Cache") != xCache || w.Body.String() != body || *calls != wantCalls {
+			t.Fatalf("expect %s %q after %d calls, got %s %q after %d calls",
+				xCache, body, wantCalls, w.Header().Get("X-Cache"), w.Body.String(), *calls)
+		}
+	}
+
+	expect(get("/users/1?page=2&utm=a"), "MISS", "user 1 page 2", 1)
+	now = now.Add(5 * time.Second)
+	w := get("/users/1?utm=b&page=2")
+	expect(w, "HIT", "user 1 page 2", 1)
+	if w.Header().Get("Age") != "5" || w.Header().Get("X-Calls") != "x" {
+		t.Fatalf("unexpected cached headers %v", w.Header())
+	}
+	expect(get("/users/1?page=3"), "MISS", "user 1 page 3", 2)
+	expect(get("/users/1?page=2", "Accept-Language", "zh"), "MISS", "user 1 page 2", 3)
+	expect(get("/users/1?page=2", "Cache-Control", "no-cache"), "MISS", "user 1 page 2", 4)
+	expect(get("/users/1?page=2", "Cache-Control", "no-store"), "BYPASS", "user 1 page 2", 5)
+	expect(get("/users/1?page=2"), "HIT", "user 1 page 2", 5)
+
+	// 标签失效
+	store.InvalidateTags("user:2")
+	expect(get("/users/1?page=2"), "HIT", "user 1 page 2", 5)
+	store.InvalidateTags("user:1")
+	expect(get("/users/1?page=2"), "MISS", "user 1 page 2", 6)
+	expect(get("/users/1?page=2"), "HIT", "user 1 page 2", 6)
+
+	// 过期
+	now = now.Add(2 * time.Minute)
+	expect(get("/users/1?page=2"), "MISS", "user 1 page 2", 7)
+
+	// 不可缓存的响应
+	expect(get("/private"), "MISS", "private", 8)
+	expect(get("/private"), "MISS", "private", 9)
+	expect(get("/missing"), "MISS", "missing", 10)
+	expect(get("/missing"), "MISS", "missing", 11)
+}
+
+func TestCacheLRUStore(t *testing.T) {
+	testCacheStore(t, NewLRUCacheStore(1<<20))
+}
+
+func TestCacheGroupStore(t *testing.T) {
+	testCacheStore(t, NewGroupCacheStore("gee-test-responses", 1<<20))
+}
+
+func TestCacheHead(t *testing.T) {
+	r := gee.New()
+	r.Use(Cache(CacheConfig{}))
+	r.HEAD("/", func(c *gee.Context) {
+		c.SetHeader("Content-Length", "2")
+		c.Status(http.StatusOK)
+	})
+	serve(r, httptest.NewRequest("HEAD", "/", nil))
+	w := serve(r, httptest.NewRequest("HEAD", "/", nil))
+	if w.Header().Get("X-Cache") != "HIT" || w.Header().Get("Content-Length") != "2" || w.Body.Len() != 0 {
+		t.Fatalf("unexpected HEAD response %v %q", w.Header(), w.Body.String())
+	}
+}
+
+func TestCacheWithMiddlewares(t *testing.T) {
+	calls := 0
+	r := gee.New()
+	cors := DefaultCORSConfig()
+	cors.AllowOrigins = []string{"https://a.com", "https://b.com"}
+	r.Use(RequestID(), CORS(cors), Compress(gzip.DefaultCompression), Cache(CacheConfig{}))
+	r.GET("/", func(c *gee.Context) {
+		calls++
+		c.SetHeader("X-Handler", "yes")
+		c.String(http.StatusOK, strings.Repeat("gee ", 100))
+	})
+	get := func(origin string, gzipped bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", origin)
+		if gzipped {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		return serve(r, req)
+	}
+
+	first := get("https://a.com", false)
+	tests := []struct {
+		origin  string
+		gzipped bool
+	}{
+		{"https://b.com", true},
+		{"https://b.com", false},
+	}
+	for _, tt := range tests {
+		w := get(tt.origin, tt.gzipped)
+		if w.Header().Get("X-Cache") != "HIT" || calls != 1 || w.Header().Get("X-Handler") != "yes" {
+			t.Fatalf("expect a cached response, got %v after %d calls", w.Header(), calls)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.origin {
+			t.Fatalf("expect Access-Control-Allow-Origin %s, got %s", tt.origin, got)
+		}
+		if id := w.Header().Get(RequestIDHeader); id == "" || id == first.Header().Get(RequestIDHeader) {
+			t.Fatalf("request ID should not be replayed from cache, got %q", id)
+		}
+		body := io.Reader(w.Body)
+		if tt.gzipped {
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = zr
+		} else if w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("response should not be compressed, got %v", w.Header())
+		}
+		if b, _ := io.ReadAll(body); string(b) != strings.Repeat("gee ", 100) {
+			t.Fatalf("unexpected body %q", b)
+		}
+	}
+}
+
+func TestCacheCompressedInside(t *testing.T) {
+	for _, headers := range [][]string{nil, {"Accept-Encoding"}} {
+		calls := 0
+		r := gee.New()
+		r.Use(Cache(CacheConfig{Headers: headers}), Compress(gzip.DefaultCompression))
+		r.GET("/", func(c *gee.Context) {
+			calls++
+			c.String(http.StatusOK, strings.Repeat("gee ", 100))
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		serve(r, req)
+		serve(r, req)
+		w := serve(r, httptest.NewRequest("GET", "/", nil))
+		// 压缩的响应只有按 Accept-Encoding 区分时才能缓存
+		wantCalls := 3
+		if headers != nil {
+			wantCalls = 2
+		}
+		if calls != wantCalls || w.Header().Get("Content-Encoding") != "" || w.Body.String() != strings.Repeat("gee ", 100) {
+			t.Fatalf("headers %v: unexpected response %v %q after %d calls", headers, w.Header(), w.Body.String(), calls)
+		}
+	}
+}
+
+func TestCachePrivateRequest(t *testing.T) {
+	calls := 0
+	newEngine := func(headers ...string) *gee.Engine {
+		calls = 0
+		r := gee.New()
+		r.Use(Cache(CacheConfig{Headers: headers}))
+		r.GET("/profile", func(c *gee.Context) {
+			calls++
+			c.String(http.StatusOK, "profile of %s%s", c.GetHeader("Cookie"), c.GetHeader("Authorization"))
+		})
+		return r
+	}
+	get := func(r *gee.Engine, name, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/profile", nil)
+		req.Header.Set(name, value)
+		return serve(r, req)
+	}
+
+	for _, name := range []string{"Cookie", "Authorization"} {
+		r := newEngine()
+		get(r, name, "alice")
+		if w := get(r, name, "bob"); w.Header().Get("X-Cache") != "BYPASS" || w.Body.String() != "profile of bob" || calls != 2 {
+			t.Fatalf("%s: request should bypass the cache, got %v %q", name, w.Header(), w.Body.String())
+		}
+
+		// 加入 Headers 后按各自的值缓存
+		r = newEngine(strings.ToLower(name))
+		get(r, name, "alice")
+		get(r, name, "bob")
+		if w := get(r, name, "alice"); w.Header().Get("X-Cache") != "HIT" || w.Body.String() != "profile of alice" || calls != 2 {
+			t.Fatalf("%s: opted-in request should be cached per value, got %v %q after %d calls", name, w.Header(), w.Body.String(), calls)
+		}
+	}
+}
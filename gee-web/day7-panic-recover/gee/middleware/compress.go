@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gee"
+)
+
+// Compress 按 Accept-Encoding 使用 gzip 或 deflate 压缩响应，level 取值同 compress/flate。
+// 升级连接、HEAD 请求、204/304 响应以及已经设置 Content-Encoding 的响应不会被压缩
+func Compress(level int) gee.HandlerFunc {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		panic(err)
+	}
+	return func(c *gee.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Req.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, level: level}
+		c.Writer = w
+		defer func() {
+			w.Close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding 在 gzip 和 deflate 中挑选 q 值最高的，同等时优先 gzip
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "deflate" {
+			continue
+		}
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && key == "q" {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				q = v
+			}
+		}
+		if q > bestQ || q == bestQ && name == "gzip" {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter 在写入第一个字节时才决定是否压缩并设置 Content-Encoding，
+// 只有状态码没有响应体的响应（例如 AbortWithStatus）不会带上压缩头
+type compressWriter struct {
+	gee.ResponseWriter
+	encoding string
+	level    int
+	writer   io.WriteCloser
+	// started 表示已经决定是否压缩，passThrough 表示不压缩
+	started     bool
+	passThrough bool
+}
+
+// start 在第一次写入时检查状态码和响应头，响应头已经写出的响应不再压缩
+func (w *compressWriter) start(b []byte) {
+	w.started = true
+	header, status := w.Header(), w.Status()
+	if len(b) == 0 || w.Written() || header.Get("Content-Encoding") != "" ||
+		status == http.StatusNoContent || status == http.StatusNotModified || status < http.StatusOK {
+		w.passThrough = true
+		return
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(b))
+	}
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	if w.encoding == "gzip" {
+		w.writer, _ = gzip.NewWriterLevel(w.ResponseWriter, w.level)
+	} else {
+		w.writer, _ = flate.NewWriter(w.ResponseWriter, w.level)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.start(b)
+	}
+	if w.passThrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+func (w *compressWriter) Flush() {
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressWriter) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Close()
+}
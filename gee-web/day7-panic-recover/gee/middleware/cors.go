@@ -0,0 +1,115 @@
+// Package middleware 提供 gee 常用的中间件
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gee"
+)
+
+// CORSConfig 配置跨域访问，AllowOrigins 中的 "*" 表示允许任意来源
+type CORSConfig struct {
+	AllowOrigins []string
+	// AllowOriginFunc 不为空时优先于 AllowOrigins 判断来源
+	AllowOriginFunc  func(origin string) bool
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge 是预检结果的缓存时间
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig 允许任意来源使用常用方法
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		MaxAge:       12 * time.Hour,
+	}
+}
+
+// CORS 处理跨域请求，预检请求直接以 204 应答并中止调用链，来源不被允许时返回 403。
+// AllowCredentials 时必须用 AllowOriginFunc 或明确的 AllowOrigins 列出来源，不能使用 "*"
+func CORS(config CORSConfig) gee.HandlerFunc {
+	allowAll := false
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+	if allowAll && config.AllowCredentials && config.AllowOriginFunc == nil {
+		// 回显任意来源并允许凭证，等于允许任意网站读取用户登录后的数据
+		panic("gee: CORS cannot allow credentials for all origins")
+	}
+	allowed := func(origin string) bool {
+		if config.AllowOriginFunc != nil {
+			return config.AllowOriginFunc(origin)
+		}
+		if allowAll {
+			return true
+		}
+		for _, o := range config.AllowOrigins {
+			if strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+	methods := strings.Join(config.AllowMethods, ", ")
+	headers := strings.Join(config.AllowHeaders, ", ")
+	expose := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge / time.Second))
+
+	return func(c *gee.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		preflight := c.Req.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		if allowAll && config.AllowOriginFunc == nil {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Add("Vary", "Origin")
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if expose != "" {
+				header.Set("Access-Control-Expose-Headers", expose)
+			}
+			c.Next()
+			return
+		}
+
+		if methods != "" {
+			header.Set("Access-Control-Allow-Methods", methods)
+		}
+		if headers != "" {
+			header.Set("Access-Control-Allow-Headers", headers)
+		} else if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
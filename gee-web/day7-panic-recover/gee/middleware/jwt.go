@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gee"
+)
+
+// Claims 是 JWT 的负载，数值类型的声明解码后为 float64
+type Claims map[string]any
+
+var (
+	ErrTokenMalformed   = errors.New("token is malformed")
+	ErrTokenSignature   = errors.New("token signature is invalid")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	ErrTokenAudience    = errors.New("token audience is invalid")
+)
+
+// JWTConfig 配置 HS256 JWT 的校验。Keys 以 kid 为 key，轮换密钥时新旧 kid 可以同时存在，
+// 没有 kid 的 token 使用 Keys[""]
+type JWTConfig struct {
+	Keys map[string][]byte
+	// Audience 不为空时 token 的 aud 必须包含它
+	Audience string
+	// Leeway 是校验 exp 和 nbf 时允许的时钟偏差
+	Leeway time.Duration
+	// Now 用于测试时固定当前时间，默认为 time.Now
+	Now func() time.Time
+}
+
+// JWT 从 Authorization: Bearer 中读取 token，校验通过后把 Claims 保存到 PrincipalKey
+func JWT(config JWTConfig) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		auth := c.GetHeader("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || token == "" {
+			c.SetHeader("WWW-Authenticate", `Bearer realm="gee"`)
+			c.Fail(http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		claims, err := ParseJWT(token, config)
+		if err != nil {
+			c.SetHeader("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, err.Error()))
+			c.Fail(http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.Set(PrincipalKey, claims)
+		c.Next()
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// ParseJWT 校验签名和 exp、nbf、aud，只接受 HS256
+func ParseJWT(token string, config JWTConfig) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unexpected signing method %q", header.Alg)
+	}
+	key, ok := config.Keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if !hmac.Equal(signature, sign(parts[0]+"."+parts[1], key)) {
+		return nil, ErrTokenSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	// 时间声明必须是数字，否则忽略它们会让 "exp":"1" 这样的 token 永不过期
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if v, ok := claims[name]; ok {
+			if _, ok := v.(float64); !ok {
+				return nil, ErrTokenMalformed
+			}
+		}
+	}
+	now := time.Now()
+	if config.Now != nil {
+		now = config.Now()
+	}
+	if exp, ok := claims.time("exp"); ok && !now.Before(exp.Add(config.Leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Before(nbf.Add(-config.Leeway)) {
+		return nil, ErrTokenNotValidYet
+	}
+	if config.Audience != "" && !claims.hasAudience(config.Audience) {
+		return nil, ErrTokenAudience
+	}
+	return claims, nil
+}
+
+// SignJWT 用 HS256 签发 token，kid 为空时不写入头部
+func SignJWT(claims Claims, kid string, key []byte) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sign(unsigned, key)), nil
+}
+
+func sign(unsigned string, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (claims Claims) time(name string) (time.Time, bool) {
+	v, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
+}
+
+// hasAudience 兼容 aud 为字符串或字符串数组两种写法
+func (claims Claims) hasAudience(audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
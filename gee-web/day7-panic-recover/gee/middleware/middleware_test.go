@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gee"
+)
+
+func serve(r *gee.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	r := gee.New()
+	config := DefaultCORSConfig()
+	config.AllowOrigins = []string{"https://geektutu.com"}
+	config.ExposeHeaders = []string{"X-Request-ID"}
+	r.Use(CORS(config))
+	r.POST("/api", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("OPTIONS", "/api", nil)
+	req.Header.Set("Origin", "https://geektutu.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := serve(r, req)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://geektutu.com" ||
+		w.Header().Get("Access-Control-Allow-Methods") == "" || w.Header().Get("Access-Control-Max-Age") != "43200" {
+		t.Fatalf("unexpected preflight response %d %v", w.Code, w.Header())
+	}
+
+	req = httptest.NewRequest("OPTIONS", "/api", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	if w := serve(r, req); w.Code != http.StatusForbidden {
+		t.Fatalf("preflight from disallowed origin should be 403, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api", nil)
+	req.Header.Set("Origin", "https://geektutu.com")
+	w = serve(r, req)
+	if w.Body.String() != "ok" || w.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Fatalf("unexpected CORS response %q %v", w.Body.String(), w.Header())
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	r := gee.New()
+	config := DefaultCORSConfig()
+	config.AllowCredentials = true
+	config.AllowOriginFunc = func(origin string) bool { return origin == "https://geektutu.com" }
+	r.Use(CORS(config))
+	r.GET("/api", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	for _, origin := range []string{"https://geektutu.com", "https://evil.example"} {
+		req := httptest.NewRequest("GET", "/api", nil)
+		req.Header.Set("Origin", origin)
+		w := serve(r, req)
+		allowed := origin == "https://geektutu.com"
+		if (w.Header().Get("Access-Control-Allow-Origin") == origin) != allowed ||
+			(w.Header().Get("Access-Control-Allow-Credentials") == "true") != allowed {
+			t.Fatalf("%s: unexpected CORS headers %v", origin, w.Header())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("credentials with AllowOrigins * should panic")
+		}
+	}()
+	config.AllowOriginFunc = nil
+	CORS(config)
+}
+
+func TestCompress(t *testing.T) {
+	r := gee.New()
+	r.Use(Compress(gzip.BestSpeed))
+	body := strings.Repeat("geektutu ", 100)
+	r.GET("/text", func(c *gee.Context) {
+		c.String(http.StatusOK, body)
+	})
+	r.GET("/empty", func(c *gee.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/denied", func(c *gee.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+
+	req := httptest.NewRequest("GET", "/text", nil)
+	req.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip")
+	w := serve(r, req)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("response should be gzipped, got %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != body {
+		t.Fatal("unexpected decompressed body")
+	}
+
+	req = httptest.NewRequest("GET", "/empty", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	if w := serve(r, req); w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("204 response should not be compressed")
+	}
+
+	req = httptest.NewRequest("GET", "/denied", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	if w := serve(r, req); w.Code != http.StatusUnauthorized || w.Body.Len() != 0 ||
+		w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" {
+		t.Fatalf("response without body should not be compressed, got %d %v", w.Code, w.Header())
+	}
+
+	if w := serve(r, httptest.NewRequest("GET", "/text", nil)); w.Body.String() != body {
+		t.Fatal("response should not be compressed without Accept-Encoding")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	r := gee.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gee.Context) {
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	w := serve(r, httptest.NewRequest("GET", "/", nil))
+	if id := w.Header().Get(RequestIDHeader); len(id) != 32 || w.Body.String() != id {
+		t.Fatalf("request id should be generated, got %q %q", id, w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "upstream-id")
+	if w := serve(r, req); w.Body.String() != "upstream-id" {
+		t.Fatalf("request id should be propagated, got %q", w.Body.String())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	r := gee.New()
+	var status int
+	r.Use(func(c *gee.Context) {
+		c.Next()
+		status = c.Writer.Status()
+	})
+	r.Use(Timeout(50 * time.Millisecond))
+	r.GET("/slow", func(c *gee.Context) {
+		select {
+		case <-c.Req.Context().Done():
+		case <-time.After(time.Second):
+		}
+		c.String(http.StatusOK, "too late")
+	})
+	r.GET("/fast", func(c *gee.Context) {
+		c.String(http.StatusCreated, "fast")
+	})
+
+	w := serve(r, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || status != http.StatusServiceUnavailable || w.Body.String() != "Service Unavailable" {
+		t.Fatalf("slow handler should time out, got %d %q", w.Code, w.Body.String())
+	}
+	w = serve(r, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" {
+		t.Fatalf("fast handler should pass through, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := gee.New()
+	r.Use(BodyLimit(8))
+	r.POST("/", func(c *gee.Context) {
+		_, err := io.ReadAll(c.Req.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Fail(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	if w := serve(r, httptest.NewRequest("POST", "/", strings.NewReader("small"))); w.Code != http.StatusOK {
+		t.Fatalf("small body should be accepted, got %d", w.Code)
+	}
+	if w := serve(r, httptest.NewRequest("POST", "/", strings.NewReader("too large body"))); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large body should be rejected, got %d", w.Code)
+	}
+	req := httptest.NewRequest("POST", "/", io.NopCloser(strings.NewReader("too large body")))
+	req.ContentLength = -1
+	if w := serve(r, req); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("chunked large body should be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gee"
+)
+
+// LimitState 是单个 key 的限流状态，由 RateLimitStore 保存，各字段的含义由 Limiter 决定
+type LimitState struct {
+	Value float64
+	Prev  float64
+	Time  time.Time
+}
+
+// RateLimitResult 是一次取令牌的结果
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset 是额度完全恢复前的时间
+	Reset time.Duration
+	// RetryAfter 是被拒绝后需要等待的时间
+	RetryAfter time.Duration
+}
+
+// Limiter 是限流算法，Take 根据状态决定是否放行并更新状态
+type Limiter interface {
+	Take(state *LimitState, now time.Time) RateLimitResult
+	// TTL 是状态闲置多久后与新状态等价，存储可以据此清理
+	TTL() time.Duration
+}
+
+// TokenBucket 令牌桶，每秒补充 Rate 个令牌，最多积攒 Burst 个
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+func (b TokenBucket) Take(state *LimitState, now time.Time) RateLimitResult {
+	burst := float64(b.Burst)
+	tokens := burst
+	if !state.Time.IsZero() {
+		tokens = math.Min(burst, state.Value+now.Sub(state.Time).Seconds()*b.Rate)
+	}
+	result := RateLimitResult{Limit: b.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = b.duration(1 - tokens)
+	}
+	state.Value, state.Time = tokens, now
+	result.Remaining = int(tokens)
+	result.Reset = b.duration(burst - tokens)
+	return result
+}
+
+func (b TokenBucket) TTL() time.Duration {
+	return b.duration(float64(b.Burst))
+}
+
+// duration 返回补充 tokens 个令牌需要的时间
+func (b TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / b.Rate * float64(time.Second)))
+}
+
+// SlidingWindow 滑动窗口计数，任意 Window 时长内最多放行约 Limit 个请求。
+// 用上一个固定窗口的计数按重叠比例估算，每个 key 只需保存两个计数
+type SlidingWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (w SlidingWindow) Take(state *LimitState, now time.Time) RateLimitResult {
+	start := now.Truncate(w.Window)
+	if !start.Equal(state.Time) {
+		if start.Sub(state.Time) == w.Window {
+			state.Prev = state.Value
+		} else {
+			state.Prev = 0
+		}
+		state.Value, state.Time = 0, start
+	}
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(w.Window)
+	limit := float64(w.Limit)
+	count := state.Prev*weight + state.Value
+
+	result := RateLimitResult{Limit: w.Limit}
+	if count+1 <= limit {
+		state.Value++
+		count++
+		result.Allowed = true
+	} else if state.Value+1 <= limit {
+		// 等上一个窗口的权重降到能容下一个请求
+		wait := 1 - (limit-1-state.Value)/state.Prev
+		result.RetryAfter = time.Duration(wait*float64(w.Window)) - elapsed
+	} else {
+		// 当前窗口已满，要等到下一个窗口里它的权重降下来
+		wait := 1 - (limit-1)/state.Value
+		result.RetryAfter = w.Window - elapsed + time.Duration(wait*float64(w.Window))
+	}
+	// 当前窗口的计数要到下一个窗口结束才完全失效
+	switch {
+	case state.Value > 0:
+		result.Reset = 2*w.Window - elapsed
+	case state.Prev > 0:
+		result.Reset = w.Window - elapsed
+	}
+	result.Remaining = int(math.Max(0, limit-math.Ceil(count)))
+	return result
+}
+
+func (w SlidingWindow) TTL() time.Duration {
+	return 2 * w.Window
+}
+
+// RateLimitStore 保存每个 key 的限流状态，Take 需要原子地读取、调用 limiter 并写回
+type RateLimitStore interface {
+	Take(key string, limiter Limiter, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	// Limiter 是默认的限流算法
+	Limiter Limiter
+	// Policy 为每个 key 选择限流算法，返回 nil 表示不限流，未设置时使用 Limiter
+	Policy func(c *gee.Context, key string) Limiter
+	// KeyFunc 决定按什么限流，默认为 KeyByIP
+	KeyFunc func(c *gee.Context) string
+	// Store 默认为 NewMemoryStore()
+	Store RateLimitStore
+	// Now 用于测试，默认为 time.Now
+	Now func() time.Time
+}
+
+// KeyByIP 按客户端 IP 限流
+func KeyByIP(c *gee.Context) string {
+	return c.ClientIP()
+}
+
+// KeyByRoute 按路由模式限流，同一路由的所有客户端共享额度
+func KeyByRoute(c *gee.Context) string {
+	return c.Req.Method + " " + c.FullPath()
+}
+
+// KeyByIPAndRoute 每个客户端在每条路由上有独立的额度
+func KeyByIPAndRoute(c *gee.Context) string {
+	return c.ClientIP() + " " + KeyByRoute(c)
+}
+
+// checkLimiter 在配置无效时 panic，Rate 为 0 的令牌桶会算出负数的 Retry-After
+func checkLimiter(limiter Limiter) {
+	switch l := limiter.(type) {
+	case *TokenBucket:
+		checkLimiter(*l)
+	case TokenBucket:
+		if !(l.Rate > 0) || l.Burst < 1 {
+			panic(fmt.Sprintf("gee: invalid TokenBucket %+v, Rate must be positive and Burst at least 1", l))
+		}
+	case *SlidingWindow:
+		checkLimiter(*l)
+	case SlidingWindow:
+		if l.Limit < 1 || l.Window <= 0 {
+			panic(fmt.Sprintf("gee: invalid SlidingWindow %+v, Limit must be at least 1 and Window positive", l))
+		}
+	}
+}
+
+// RateLimit 超出限制时返回 429 和 Retry-After，并在响应头中给出 X-RateLimit-Limit、
+// X-RateLimit-Remaining 和 X-RateLimit-Reset（秒）。存储出错时记录日志并放行。
+// TokenBucket 和 SlidingWindow 的配置无效时 panic，Policy 返回的限流算法在使用时检查
+func RateLimit(config RateLimitConfig) gee.HandlerFunc {
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	if config.Limiter != nil {
+		checkLimiter(config.Limiter)
+	}
+	if config.Policy == nil {
+		if config.Limiter == nil {
+			panic("gee: RateLimit requires a Limiter or a Policy")
+		}
+		config.Policy = func(*gee.Context, string) Limiter { return config.Limiter }
+	}
+	return func(c *gee.Context) {
+		key := config.KeyFunc(c)
+		limiter := config.Policy(c, key)
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		checkLimiter(limiter)
+		result, err := config.Store.Take(key, limiter, config.Now())
+		if err != nil {
+			log.Printf("ratelimit: %v", err)
+			c.Next()
+			return
+		}
+		c.SetHeader("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.SetHeader("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.SetHeader("X-RateLimit-Reset", seconds(result.Reset))
+		if !result.Allowed {
+			c.SetHeader("Retry-After", seconds(result.RetryAfter))
+			c.Fail(http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds 向上取整到秒
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
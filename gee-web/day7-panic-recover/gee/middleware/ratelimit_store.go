@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"geecache/lru"
+)
+
+// MemoryStore 把限流状态保存在进程内存中，闲置超过 Limiter.TTL 的状态会被定期清理
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*memoryState
+	// 下一次清理的时间
+	sweepAt time.Time
+}
+
+type memoryState struct {
+	LimitState
+	expire time.Time
+}
+
+// memorySweepInterval 是 MemoryStore 两次清理的最小间隔
+const memorySweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*memoryState)}
+}
+
+func (s *MemoryStore) Take(key string, limiter Limiter, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.After(s.sweepAt) {
+		for k, state := range s.states {
+			if now.After(state.expire) {
+				delete(s.states, k)
+			}
+		}
+		s.sweepAt = now.Add(memorySweepInterval)
+	}
+	state, ok := s.states[key]
+	if !ok || now.After(state.expire) {
+		state = &memoryState{}
+		s.states[key] = state
+	}
+	result := limiter.Take(&state.LimitState, now)
+	state.expire = now.Add(limiter.TTL())
+	return result, nil
+}
+
+// LRUStore 使用 geecache 的 LRU 缓存保存限流状态，内存占用不超过 maxBytes，
+// 超出时淘汰最久未访问的 key
+type LRUStore struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+type lruState struct {
+	LimitState
+	expire time.Time
+}
+
+// Len 实现 lru.Value，按结构体大小估算
+func (s *lruState) Len() int {
+	return 64
+}
+
+func NewLRUStore(maxBytes int64) *LRUStore {
+	return &LRUStore{cache: lru.New(maxBytes, nil)}
+}
+
+func (s *LRUStore) Take(key string, limiter Limiter, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var state *lruState
+	if v, ok := s.cache.Get(key); ok && !now.After(v.(*lruState).expire) {
+		state = v.(*lruState)
+	} else {
+		state = &lruState{}
+		s.cache.Add(key, state)
+	}
+	result := limiter.Take(&state.LimitState, now)
+	state.expire = now.Add(limiter.TTL())
+	return result, nil
+}
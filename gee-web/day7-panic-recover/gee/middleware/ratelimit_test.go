@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gee"
+)
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := gee.New()
+	r.Use(RateLimit(RateLimitConfig{
+		Limiter: TokenBucket{Rate: 1, Burst: 2},
+		Now:     func() time.Time { return now },
+	}))
+	r.GET("/", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for i, want := range []string{"1", "0"} {
+		w := serve(r, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != want {
+			t.Fatalf("request %d: unexpected response %d %v", i, w.Code, w.Header())
+		}
+	}
+	w := serve(r, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" ||
+		w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Reset") != "2" {
+		t.Fatalf("unexpected 429 response %d %v", w.Code, w.Header())
+	}
+
+	// 其他客户端有独立的额度
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	if w := serve(r, req); w.Code != http.StatusOK {
+		t.Fatalf("another client should not be limited, got %d", w.Code)
+	}
+
+	now = now.Add(time.Second)
+	if w := serve(r, httptest.NewRequest("GET", "/", nil)); w.Code != http.StatusOK {
+		t.Fatalf("token should be refilled after 1s, got %d", w.Code)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limiter := SlidingWindow{Limit: 4, Window: time.Minute}
+	var state LimitState
+	start := time.Unix(1700000040, 0).Truncate(time.Minute)
+
+	for i := 0; i < 4; i++ {
+		if res := limiter.Take(&state, start.Add(50*time.Second)); !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if res := limiter.Take(&state, start.Add(55*time.Second)); res.Allowed || res.RetryAfter != 20*time.Second {
+		t.Fatalf("window is full, got %+v", res)
+	}
+	// 下一个窗口过去一半，上一个窗口按 4*0.5=2 计算
+	mid := start.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		if res := limiter.Take(&state, mid); !res.Allowed {
+			t.Fatalf("request %d in next window should be allowed", i)
+		}
+	}
+	if res := limiter.Take(&state, mid); res.Allowed || res.Remaining != 0 {
+		t.Fatalf("weighted count should reach the limit, got %+v", res)
+	}
+}
+
+func TestRateLimitPolicy(t *testing.T) {
+	r := gee.New()
+	r.Use(RateLimit(RateLimitConfig{
+		KeyFunc: KeyByRoute,
+		Store:   NewLRUStore(1 << 10),
+		Policy: func(c *gee.Context, key string) Limiter {
+			if c.FullPath() == "/login" {
+				return TokenBucket{Rate: 0.1, Burst: 1}
+			}
+			return nil
+		},
+	}))
+	r.POST("/login", func(c *gee.Context) {})
+	r.GET("/user/:id", func(c *gee.Context) {})
+
+	if w := serve(r, httptest.NewRequest("POST", "/login", nil)); w.Code != http.StatusOK {
+		t.Fatalf("first login should pass, got %d", w.Code)
+	}
+	if w := serve(r, httptest.NewRequest("POST", "/login", nil)); w.Code != http.StatusTooManyRequests ||
+		w.Header().Get("Retry-After") != "10" {
+		t.Fatalf("second login should be limited, got %d %v", w.Code, w.Header())
+	}
+	for i := 0; i < 3; i++ {
+		w := serve(r, httptest.NewRequest("GET", "/user/1", nil))
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("routes without policy should not be limited, got %d %v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestRateLimitInvalidConfig(t *testing.T) {
+	for _, limiter := range []Limiter{
+		TokenBucket{Rate: 0, Burst: 1},
+		TokenBucket{Rate: -1, Burst: 1},
+		&TokenBucket{Rate: 1, Burst: 0},
+		SlidingWindow{Limit: 0, Window: time.Minute},
+		SlidingWindow{Limit: 10, Window: 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%+v should panic", limiter)
+				}
+			}()
+			RateLimit(RateLimitConfig{Limiter: limiter})
+		}()
+	}
+
+	// Policy 返回的无效配置在使用时 panic，由 Recovery 返回 500，不会写出负数的 Retry-After
+	r := gee.New()
+	r.Use(gee.Recovery(), RateLimit(RateLimitConfig{
+		Policy: func(c *gee.Context, key string) Limiter { return TokenBucket{Rate: 0, Burst: 1} },
+	}))
+	r.GET("/", func(c *gee.Context) {})
+	if w := serve(r, httptest.NewRequest("GET", "/", nil)); w.Code != http.StatusInternalServerError {
+		t.Fatalf("invalid policy limiter should fail the request, got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"gee"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID 沿用请求中合法的 X-Request-ID，否则生成新的 ID。
+// ID 写入响应头，并放入请求的 context，下游调用可以通过 RequestIDFromContext 取得
+func RequestID() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.SetHeader(RequestIDHeader, id)
+		c.Req = c.Req.WithContext(context.WithValue(c.Req.Context(), requestIDKey{}, id))
+		c.Next()
+	}
+}
+
+// GetRequestID 返回 RequestID 中间件为当前请求分配的 ID
+func GetRequestID(c *gee.Context) string {
+	return RequestIDFromContext(c.Req.Context())
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID 只接受不超过 128 字节的可打印 ASCII，防止日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gee"
+)
+
+// Timeout 限制后续处理函数的执行时间，超时后立即返回 503 并中止调用链。
+// 后续处理函数在新的 goroutine 中执行，响应先写入缓冲区，因此不适用于流式响应；
+// 请求的 context 会在超时时取消，处理函数应据此尽快返回
+func Timeout(d time.Duration) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		ctx, cancel := context.WithTimeout(c.Req.Context(), d)
+		defer cancel()
+		c.Req = c.Req.WithContext(ctx)
+
+		w := c.Writer
+		tw := &timeoutWriter{ResponseWriter: w, header: make(http.Header)}
+		c.Writer = tw
+		done := make(chan struct{})
+		var p any
+		go func() {
+			defer func() {
+				p = recover()
+				close(done)
+			}()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+			c.Writer = w
+			if p != nil {
+				panic(p)
+			}
+			tw.writeTo(w)
+		case <-ctx.Done():
+			tw.timeout()
+			body := []byte(http.StatusText(http.StatusServiceUnavailable))
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write(body)
+			w.Flush()
+			// 客户端已经收到完整响应，等待处理函数退出后 Context 才能被复用
+			<-done
+			c.Writer = w
+			c.Abort()
+			// 超时后的写入失败会让 Render panic，这是预期的结果
+			if p != nil && p != http.ErrHandlerTimeout {
+				panic(p)
+			}
+		}
+	}
+}
+
+// timeoutWriter 缓冲处理函数的响应，超时后的写入返回 http.ErrHandlerTimeout。
+// 内嵌的 ResponseWriter 只用于满足接口，缓冲期间不会写入它
+type timeoutWriter struct {
+	gee.ResponseWriter
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.code != 0 {
+		return
+	}
+	w.code = code
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.code != 0
+}
+
+// Flush 不做任何事，响应在处理函数返回后一次写出
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("timeout: response is buffered and cannot be hijacked")
+}
+
+func (w *timeoutWriter) Push(string, *http.PushOptions) error {
+	return http.ErrNotSupported
+}
+
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	w.timedOut = true
+	w.mu.Unlock()
+}
+
+func (w *timeoutWriter) writeTo(dst gee.ResponseWriter) {
+	header := dst.Header()
+	for k, vs := range w.header {
+		header[k] = vs
+	}
+	if w.code != 0 {
+		dst.WriteHeader(w.code)
+	}
+	dst.Write(w.buf.Bytes())
+}
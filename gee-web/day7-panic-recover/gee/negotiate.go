@@ -0,0 +1,122 @@
+package gee
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gee/render"
+)
+
+const (
+	MIMEJSON     = render.MIMEJSON
+	MIMEXML      = render.MIMEXML
+	MIMEXML2     = render.MIMEXML2
+	MIMEHTML     = render.MIMEHTML
+	MIMEPlain    = render.MIMEPlain
+	MIMEProtoBuf = render.MIMEProtoBuf
+)
+
+// Negotiate 描述同一资源可以提供的格式，格式专属数据为空时使用 Data
+type Negotiate struct {
+	Offered  []string
+	HTMLName string
+	HTMLData any
+	JSONData any
+	XMLData  any
+	TextData string
+	Data     any
+}
+
+// Negotiate 按 Accept 头从 Offered 中挑选格式，都不接受时返回 406
+func (c *Context) Negotiate(code int, config Negotiate) {
+	switch c.NegotiateFormat(config.Offered...) {
+	case MIMEJSON:
+		c.JSON(code, pick(config.JSONData, config.Data))
+	case MIMEXML, MIMEXML2:
+		c.XML(code, pick(config.XMLData, config.Data))
+	case MIMEHTML:
+		c.HTML(code, config.HTMLName, pick(config.HTMLData, config.Data))
+	case MIMEPlain:
+		if config.TextData != "" {
+			c.String(code, config.TextData)
+		} else {
+			c.String(code, "%v", config.Data)
+		}
+	default:
+		c.Fail(http.StatusNotAcceptable, "the accepted formats are not offered by the server")
+	}
+}
+
+func pick(data, fallback any) any {
+	if data != nil {
+		return data
+	}
+	return fallback
+}
+
+// NegotiateFormat 返回客户端最偏好且服务端提供的格式，没有则返回空字符串
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		panic("gee: you must provide at least one offer")
+	}
+	accepted := parseAccept(c.Req.Header.Get("Accept"))
+	if len(accepted) == 0 {
+		return offered[0]
+	}
+	for _, accept := range accepted {
+		for _, offer := range offered {
+			if matchMIME(accept, offer) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+func matchMIME(accept, offer string) bool {
+	if accept == "*/*" || accept == offer {
+		return true
+	}
+	if strings.HasSuffix(accept, "/*") {
+		return strings.HasPrefix(offer, accept[:len(accept)-1])
+	}
+	return false
+}
+
+// parseAccept 解析 Accept 头，按 q 值从高到低排序，q=0 的类型被丢弃
+func parseAccept(header string) []string {
+	type weighted struct {
+		mime string
+		q    float64
+	}
+	items := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mime := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mime == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			items = append(items, weighted{mime: mime, q: q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+	mimes := make([]string, len(items))
+	for i, item := range items {
+		mimes[i] = item.mime
+	}
+	return mimes
+}
@@ -0,0 +1,59 @@
+package gee
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"", MIMEJSON},
+		{"application/xml;q=0.9, application/json", MIMEJSON},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", MIMEXML},
+		{"text/*", MIMEPlain},
+		{"application/json;q=0, image/png", ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", tc.accept)
+		c := NewContext(httptest.NewRecorder(), req)
+		if got := c.NegotiateFormat(MIMEJSON, MIMEXML, MIMEPlain); got != tc.want {
+			t.Fatalf("Accept %q should negotiate %q, got %q", tc.accept, tc.want, got)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	offered := []string{MIMEJSON, MIMEXML}
+
+	for accept, want := range map[string]string{
+		MIMEJSON:    `{"name":"gee"}`,
+		MIMEXML:     `<user><name>gee</name></user>`,
+		"image/png": `{"message":"the accepted formats are not offered by the server"}`,
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		c := NewContext(w, req)
+		c.Negotiate(200, Negotiate{Offered: offered, Data: user{Name: "gee"}})
+		if got := strings.TrimSpace(w.Body.String()); got != want {
+			t.Fatalf("Accept %q should render %s, got %s", accept, want, got)
+		}
+	}
+}
+
+func TestStringNotFormat(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	c.String(200, "100%s")
+	if w.Body.String() != "100%s" {
+		t.Fatalf("user text should not be treated as format, got %q", w.Body.String())
+	}
+}
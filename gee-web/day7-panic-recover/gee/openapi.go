@@ -0,0 +1,423 @@
+package gee
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// routeDoc 是注册路由时补充的文档信息，同一个 Route 的所有方法和展开的模式共用一份
+type routeDoc struct {
+	summary     string
+	description string
+	tags        []string
+	request     reflect.Type
+	responses   map[int]reflect.Type
+	hidden      bool
+}
+
+type docKey struct {
+	host, method, pattern string
+}
+
+// document 返回路由的文档信息，第一次调用时为每个方法和展开后的模式登记
+func (r *Route) document() *routeDoc {
+	if r.doc != nil {
+		return r.doc
+	}
+	r.doc = &routeDoc{responses: make(map[int]reflect.Type)}
+	if r.engine.docs == nil {
+		r.engine.docs = make(map[docKey]*routeDoc)
+	}
+	host := ""
+	if r.host != nil {
+		host = r.host.pattern
+	}
+	for _, method := range r.methods {
+		for _, pattern := range expandOptional(r.pattern) {
+			r.engine.docs[docKey{host, method, pattern}] = r.doc
+		}
+	}
+	return r.doc
+}
+
+// Summary 设置接口的简介，description 可选，是更详细的说明
+func (r *Route) Summary(summary string, description ...string) *Route {
+	doc := r.document()
+	doc.summary = summary
+	doc.description = strings.Join(description, "\n")
+	return r
+}
+
+func (r *Route) Tags(tags ...string) *Route {
+	doc := r.document()
+	doc.tags = append(doc.tags, tags...)
+	return r
+}
+
+// Request 声明请求的结构体，例如 Request(CreateUser{})。
+// GET、HEAD 和 DELETE 请求把带 form 标签的字段作为查询参数，其他方法作为 JSON 请求体
+func (r *Route) Request(v any) *Route {
+	r.document().request = reflect.TypeOf(v)
+	return r
+}
+
+// Response 声明状态码为 code 时的响应体，v 为 nil 表示没有响应体
+func (r *Route) Response(code int, v any) *Route {
+	r.document().responses[code] = reflect.TypeOf(v)
+	return r
+}
+
+// Hidden 不把路由写进 OpenAPI 文档
+func (r *Route) Hidden() *Route {
+	r.document().hidden = true
+	return r
+}
+
+// OpenAPIInfo 是文档的基本信息
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIDoc 是 OpenAPI 3 文档，只包含 gee 能生成的部分
+type OpenAPIDoc struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       OpenAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	} `json:"components"`
+}
+
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// OpenAPI 根据已注册的路由生成文档，没有注解的路由只有默认的 200 响应。
+// OpenAPI 的路径不区分主机，所以只包含 Engine 本身的路由，不包含 Host 分组的路由
+func (engine *Engine) OpenAPI(info OpenAPIInfo) *OpenAPIDoc {
+	doc := &OpenAPIDoc{OpenAPI: "3.0.3", Info: info, Paths: make(map[string]map[string]*Operation)}
+	schemas := &schemaBuilder{schemas: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+	for _, route := range engine.Routes() {
+		rd := engine.docs[docKey{route.Host, route.Method, route.Path}]
+		if route.Host != "" || rd != nil && rd.hidden {
+			continue
+		}
+		path, params := openAPIPath(route.Path)
+		op := &Operation{Parameters: params, Responses: make(map[string]*Response)}
+		if rd != nil {
+			op.Summary, op.Description, op.Tags = rd.summary, rd.description, rd.tags
+			if rd.request != nil {
+				if hasBody(route.Method) {
+					op.RequestBody = &RequestBody{Required: true, Content: jsonContent(schemas.schema(rd.request))}
+				} else {
+					op.Parameters = append(op.Parameters, schemas.queryParams(rd.request)...)
+				}
+			}
+			for code, t := range rd.responses {
+				resp := &Response{Description: http.StatusText(code)}
+				if t != nil {
+					resp.Content = jsonContent(schemas.schema(t))
+				}
+				op.Responses[strconv.Itoa(code)] = resp
+			}
+		}
+		if len(op.Responses) == 0 {
+			op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	doc.Components.Schemas = schemas.schemas
+	return doc
+}
+
+// ServeDocs 在 path 下提供文档：path/openapi.json 是 OpenAPI 文档，path 是浏览用的页面。
+// 文档在每次请求时生成，之后注册的路由也会出现在文档中
+func (engine *Engine) ServeDocs(path string, info OpenAPIInfo) {
+	path = strings.TrimSuffix(path, "/")
+	engine.GET(path+"/openapi.json", func(c *Context) {
+		c.IndentedJSON(http.StatusOK, engine.OpenAPI(info))
+	}).Hidden()
+	engine.GET(path, func(c *Context) {
+		c.SetHeader("Content-Type", MIMEHTML+"; charset=utf-8")
+		c.Status(http.StatusOK)
+		docsPage.Execute(c.Writer, map[string]string{"Title": info.Title, "Spec": path + "/openapi.json"})
+	}).Hidden()
+}
+
+// docsPage 是不依赖外部资源的文档页面，按路径列出接口，展开后显示参数和结构
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: .5em 0; padding: .5em; }
+summary { cursor: pointer; }
+.method { display: inline-block; width: 5em; font-weight: bold; text-transform: uppercase; }
+pre { background: #f6f8fa; padding: .5em; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p><a href="{{.Spec}}">openapi.json</a></p>
+<div id="ops"></div>
+<script>
+fetch({{.Spec}}).then(r => r.json()).then(doc => {
+  const ops = document.getElementById("ops");
+  for (const [path, item] of Object.entries(doc.paths)) {
+    for (const [method, op] of Object.entries(item)) {
+      const d = document.createElement("details");
+      const s = document.createElement("summary");
+      const m = document.createElement("span");
+      m.className = "method";
+      m.textContent = method;
+      s.append(m, path + (op.summary ? " - " + op.summary : ""));
+      const pre = document.createElement("pre");
+      pre.textContent = JSON.stringify(op, null, 2);
+      d.append(s, pre);
+      ops.append(d);
+    }
+  }
+  if (doc.components.schemas) {
+    const h = document.createElement("h2");
+    h.textContent = "Schemas";
+    const pre = document.createElement("pre");
+    pre.textContent = JSON.stringify(doc.components.schemas, null, 2);
+    ops.append(h, pre);
+  }
+});
+</script>
+</body>
+</html>
+`))
+
+func hasBody(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodDelete
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{MIMEJSON: {Schema: schema}}
+}
+
+// openAPIPath 把 /user/:id/{tab:alpha}/*filepath 转换为 /user/{id}/{tab}/{filepath}，并返回路径参数
+func openAPIPath(pattern string) (string, []*Parameter) {
+	parts := strings.Split(pattern, "/")
+	var params []*Parameter
+	for i, part := range parts {
+		var name, constraint string
+		switch {
+		case strings.HasPrefix(part, ":"), strings.HasPrefix(part, "*"):
+			name = part[1:]
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			part, _ = optionalSegment(part)
+			name, constraint, _ = strings.Cut(part[1:len(part)-1], ":")
+		default:
+			continue
+		}
+		parts[i] = "{" + name + "}"
+		params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: constraintSchema(constraint)})
+	}
+	return strings.Join(parts, "/"), params
+}
+
+func constraintSchema(constraint string) *Schema {
+	switch constraint {
+	case "":
+		return &Schema{Type: "string"}
+	case "int", "uint":
+		return &Schema{Type: "integer"}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	case "alpha":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z]+$"}
+	}
+	return &Schema{Type: "string", Pattern: "^(?:" + constraint + ")$"}
+}
+
+var schemaNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// schemaBuilder 把 Go 类型转换为 Schema，具名结构体放入 components 并用 $ref 引用
+type schemaBuilder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return &Schema{Ref: "#/components/schemas/" + b.component(t)}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		return b.object(t)
+	}
+	// interface 等无法确定类型的值
+	return &Schema{}
+}
+
+// component 登记具名结构体，同名的不同类型加上包名区分
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := schemaNameRe.ReplaceAllString(t.Name(), "_")
+	if _, taken := b.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = schemaNameRe.ReplaceAllString(pkg[strings.LastIndex(pkg, "/")+1:]+"."+t.Name(), "_")
+	}
+	b.names[t] = name
+	// 先占位，自引用的结构体不会无限递归
+	b.schemas[name] = &Schema{}
+	*b.schemas[name] = *b.object(t)
+	return name
+}
+
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.fields(t, func(f reflect.StructField) {
+		name, omitempty := jsonName(f)
+		if name == "" {
+			return
+		}
+		s.Properties[name] = b.schema(f.Type)
+		if !omitempty && isRequired(f) {
+			s.Required = append(s.Required, name)
+		}
+	})
+	sort.Strings(s.Required)
+	return s
+}
+
+// queryParams 把带 form 标签的字段转换为查询参数
+func (b *schemaBuilder) queryParams(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []*Parameter
+	b.fields(t, func(f reflect.StructField) {
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			return
+		}
+		params = append(params, &Parameter{Name: name, In: "query", Required: isRequired(f), Schema: b.schema(f.Type)})
+	})
+	return params
+}
+
+// fields 遍历导出字段，匿名嵌入的结构体展开到外层
+func (b *schemaBuilder) fields(t reflect.Type, visit func(f reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.fields(ft, visit)
+				continue
+			}
+		}
+		if f.IsExported() {
+			visit(f)
+		}
+	}
+}
+
+// jsonName 返回字段在 JSON 中的名字，忽略的字段返回空字符串
+func jsonName(f reflect.StructField) (name string, omitempty bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+// isRequired 读取 binding 标签中的 required，与 Bind 的校验规则一致
+func isRequired(f reflect.StructField) bool {
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
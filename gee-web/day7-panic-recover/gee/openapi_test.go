@@ -0,0 +1,100 @@
+package gee
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type apiBase struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type apiUser struct {
+	apiBase
+	Name    string            `json:"name" binding:"required"`
+	Email   string            `json:"email,omitempty" binding:"required,email"`
+	Tags    []string          `json:"tags"`
+	Friends []*apiUser        `json:"friends"`
+	Meta    map[string]string `json:"meta"`
+	secret  string
+}
+
+type apiQuery struct {
+	Page int    `form:"page" binding:"required"`
+	Sort string `form:"sort"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	r := New()
+	r.GET("/users", nil).Summary("List users").Tags("user").Request(apiQuery{}).Response(200, []apiUser{})
+	r.POST("/users", nil).Summary("Create user").Request(apiUser{}).Response(201, apiUser{}).Response(400, nil)
+	r.GET("/users/{id:int}/{tab?}", nil).Response(200, &apiUser{})
+	r.GET("/assets/*filepath", nil)
+	r.GET("/internal", nil).Hidden()
+	doc := r.OpenAPI(OpenAPIInfo{Title: "test", Version: "1.0"})
+
+	if _, ok := doc.Paths["/internal"]; ok {
+		t.Fatal("hidden route should not be documented")
+	}
+	list := doc.Paths["/users"]["get"]
+	if list.Summary != "List users" || len(list.Parameters) != 2 || list.Parameters[0].In != "query" || !list.Parameters[0].Required {
+		t.Fatalf("unexpected list operation %+v", list)
+	}
+	if items := list.Responses["200"].Content[MIMEJSON].Schema; items.Type != "array" || items.Items.Ref != "#/components/schemas/apiUser" {
+		t.Fatalf("unexpected list response %+v", items)
+	}
+	create := doc.Paths["/users"]["post"]
+	if create.RequestBody == nil || create.Responses["400"].Content != nil || create.Responses["400"].Description != "Bad Request" {
+		t.Fatalf("unexpected create operation %+v", create)
+	}
+	for _, path := range []string{"/users/{id}", "/users/{id}/{tab}"} {
+		op := doc.Paths[path]["get"]
+		if op == nil || op.Parameters[0].Schema.Type != "integer" || op.Responses["200"].Content == nil {
+			t.Fatalf("%s: unexpected operation %+v", path, op)
+		}
+	}
+	if op := doc.Paths["/assets/{filepath}"]["get"]; op == nil || op.Responses["200"] == nil {
+		t.Fatal("undocumented route should have a default response")
+	}
+
+	user := doc.Components.Schemas["apiUser"]
+	var props []string
+	for name := range user.Properties {
+		props = append(props, name)
+	}
+	if len(props) != 7 || !reflect.DeepEqual(user.Required, []string{"name"}) {
+		t.Fatalf("unexpected user schema %v, required %v", props, user.Required)
+	}
+	if user.Properties["created"].Format != "date-time" || user.Properties["friends"].Items.Ref != "#/components/schemas/apiUser" ||
+		user.Properties["meta"].AdditionalProperties.Type != "string" {
+		t.Fatal("unexpected user property schemas")
+	}
+}
+
+func TestServeDocs(t *testing.T) {
+	r := New()
+	r.ServeDocs("/docs/", OpenAPIInfo{Title: "gee api", Version: "1.0"})
+	r.GET("/ping", nil).Summary("Ping")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs/openapi.json", nil))
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc["openapi"] != "3.0.3" {
+		t.Fatalf("unexpected spec %s", w.Body.String())
+	}
+	paths := doc["paths"].(map[string]any)
+	if len(paths) != 1 || paths["/ping"] == nil {
+		t.Fatalf("docs routes should be hidden, got %v", paths)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<title>gee api</title>") {
+		t.Fatalf("unexpected docs page %d %s", w.Code, w.Body.String())
+	}
+}
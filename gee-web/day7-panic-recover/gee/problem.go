@@ -0,0 +1,29 @@
+package gee
+
+import "net/http"
+
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem 是 RFC 7807 定义的错误文档
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// Problem 以 application/problem+json 返回错误，Status 和 Title 为空时自动补全
+func (c *Context) Problem(p Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Path
+	}
+	c.Writer.Header().Set("Content-Type", MIMEProblemJSON)
+	c.JSON(p.Status, p)
+}
@@ -0,0 +1,32 @@
+package gee
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+)
+
+// RecoveryFunc 处理已捕获的 panic，调用时后续的处理函数已被跳过
+type RecoveryFunc func(c *Context, err any)
+
+func Recovery() HandlerFunc {
+	return CustomRecovery(func(c *Context, err any) {
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+	})
+}
+
+// CustomRecovery 打印堆栈后交给 handle 生成响应，例如渲染错误页或 Problem 文档
+func CustomRecovery(handle RecoveryFunc) HandlerFunc {
+	return func(ctx *Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				buf := make([]byte, 4096)
+				n := runtime.Stack(buf, false)
+				log.Printf("Panic: %+v\n%s", r, buf[:n])
+				ctx.Abort()
+				handle(ctx, r)
+			}
+		}()
+		ctx.Next()
+	}
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEXML2     = "text/xml"
+	MIMEHTML     = "text/html"
+	MIMEPlain    = "text/plain"
+	MIMEProtoBuf = "application/x-protobuf"
+)
+
+// Render 负责把数据写入响应，gee.Context.Render 会先写 Content-Type 和状态码
+type Render interface {
+	Render(w http.ResponseWriter) error
+	WriteContentType(w http.ResponseWriter)
+}
+
+func writeContentType(w http.ResponseWriter, value string) {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", value)
+	}
+}
+
+// JSON 可以序列化任意值
+type JSON struct {
+	Data any
+}
+
+func (r JSON) Render(w http.ResponseWriter) error {
+	bytes, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes)
+	return err
+}
+
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, MIMEJSON+"; charset=utf-8")
+}
+
+type IndentedJSON struct {
+	Data any
+}
+
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	bytes, err := json.MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes)
+	return err
+}
+
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, MIMEJSON+"; charset=utf-8")
+}
+
+type XML struct {
+	Data any
+}
+
+func (r XML) Render(w http.ResponseWriter) error {
+	return xml.NewEncoder(w).Encode(r.Data)
+}
+
+func (r XML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, MIMEXML+"; charset=utf-8")
+}
+
+// Text 没有参数时原样输出 Format，避免用户文本被当作格式串
+type Text struct {
+	Format string
+	Data   []any
+}
+
+func (r Text) Render(w http.ResponseWriter) (err error) {
+	if len(r.Data) > 0 {
+		_, err = fmt.Fprintf(w, r.Format, r.Data...)
+		return
+	}
+	_, err = w.Write([]byte(r.Format))
+	return
+}
+
+func (r Text) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, MIMEPlain+"; charset=utf-8")
+}
+
+// ProtoBuf 写出已编码的 protobuf 字节，编码由调用方完成
+type ProtoBuf struct {
+	Data []byte
+}
+
+func (r ProtoBuf) Render(w http.ResponseWriter) error {
+	_, err := w.Write(r.Data)
+	return err
+}
+
+func (r ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, MIMEProtoBuf)
+}
+
+// Data 写出任意字节和指定的 Content-Type
+type Data struct {
+	ContentType string
+	Data        []byte
+}
+
+func (r Data) Render(w http.ResponseWriter) error {
+	_, err := w.Write(r.Data)
+	return err
+}
+
+func (r Data) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, r.ContentType)
+}
+
+// Redirect 自己写状态码，gee.Context.Render 需传入 -1
+type Redirect struct {
+	Code     int
+	Request  *http.Request
+	Location string
+}
+
+func (r Redirect) Render(w http.ResponseWriter) error {
+	if (r.Code < http.StatusMultipleChoices || r.Code > http.StatusPermanentRedirect) && r.Code != http.StatusCreated {
+		return fmt.Errorf("cannot redirect with status code %d", r.Code)
+	}
+	http.Redirect(w, r.Request, r.Location, r.Code)
+	return nil
+}
+
+func (r Redirect) WriteContentType(http.ResponseWriter) {}
+
+// File 由 http.ServeFile 处理 Range 和缓存头，Name 非空时作为附件下载
+type File struct {
+	Path    string
+	Name    string
+	Request *http.Request
+}
+
+func (r File) Render(w http.ResponseWriter) error {
+	if r.Name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": r.Name}))
+	}
+	http.ServeFile(w, r.Request, r.Path)
+	return nil
+}
+
+func (r File) WriteContentType(http.ResponseWriter) {}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const MIMEEventStream = "text/event-stream"
+
+// SSEvent 是一条 Server-Sent Event，Data 为字符串或 []byte 时原样输出，其余类型编码为 JSON
+type SSEvent struct {
+	Event string
+	ID    string
+	Retry uint
+	Data  any
+}
+
+func (r SSEvent) Render(w http.ResponseWriter) error {
+	var b strings.Builder
+	if r.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", escapeField(r.ID))
+	}
+	if r.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", escapeField(r.Event))
+	}
+	if r.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", r.Retry)
+	}
+	data, err := eventData(r.Data)
+	if err != nil {
+		return err
+	}
+	// 多行数据每行都要加 data: 前缀
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func (r SSEvent) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	header.Set("Content-Type", MIMEEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+}
+
+func eventData(data any) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	bytes, err := json.Marshal(data)
+	return string(bytes), err
+}
+
+// escapeField 去掉字段中的换行，避免注入额外的字段
+func escapeField(s string) string {
+	return strings.NewReplacer("\n", "", "\r", "").Replace(s)
+}
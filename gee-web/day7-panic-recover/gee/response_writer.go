@@ -0,0 +1,114 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter 在 http.ResponseWriter 的基础上记录状态码和响应体大小。
+// WriteHeader 只记下状态码，第一次 Write 或 WriteHeaderNow 时才真正写出，
+// 因此重复调用 WriteHeader 不会产生 superfluous WriteHeader 警告
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	// Status 返回响应的状态码，尚未设置时为 200
+	Status() int
+	// Size 返回已写出的响应体字节数
+	Size() int
+	// Written 报告响应头是否已经写出
+	Written() bool
+	// WriteHeaderNow 立即写出响应头
+	WriteHeaderNow()
+}
+
+var errNotHijacker = errors.New("gee: response does not implement http.Hijacker")
+
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	size    int
+	written bool
+}
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = http.StatusOK
+	w.size = 0
+	w.written = false
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.written || code <= 0 {
+		return
+	}
+	// 1xx 信息响应（如 103 Early Hints）可以在最终响应前发送多次
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.written {
+		w.written = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.written
+}
+
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 接管底层连接，之后的写入由调用方负责。接管通常用于协议升级，状态码记为 101
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errNotHijacker
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && !w.written {
+		w.written = true
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Push 发起 HTTP/2 服务端推送，底层连接不支持时返回 http.ErrNotSupported
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap 供 http.ResponseController 访问底层的 ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	r := New()
+	var status, size int
+	var written bool
+	r.Use(func(c *Context) {
+		c.Next()
+		status, size, written = c.Writer.Status(), c.Writer.Size(), c.Writer.Written()
+	})
+	r.GET("/string", func(c *Context) {
+		c.String(http.StatusAccepted, "hello")
+		// 响应头已经写出，后面的状态码被忽略
+		c.Status(http.StatusInternalServerError)
+	})
+	r.GET("/status", func(c *Context) {
+		c.Status(http.StatusBadRequest)
+		c.Status(http.StatusUnauthorized)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/string", nil))
+	if w.Code != http.StatusAccepted || status != http.StatusAccepted || size != 5 || !written {
+		t.Fatalf("unexpected state %d %d %d %v", w.Code, status, size, written)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if w.Code != http.StatusUnauthorized || status != http.StatusUnauthorized || size != 0 || written {
+		t.Fatalf("status without body should be written at the end, got %d %d %d %v", w.Code, status, size, written)
+	}
+}
+
+func TestResponseWriterUnsupported(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, _, err := c.Writer.Hijack(); err == nil {
+		t.Fatal("Hijack should fail when the underlying writer is not a Hijacker")
+	}
+	if err := c.Writer.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Fatalf("Push should return http.ErrNotSupported, got %v", err)
+	}
+	if rc := http.NewResponseController(c.Writer); rc.Flush() != nil || !c.Writer.Written() {
+		t.Fatal("ResponseController should reach the underlying writer")
+	}
+}
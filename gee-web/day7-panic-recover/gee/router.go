@@ -1,5 +1,28 @@
 package gee
 
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+var ErrNotFound = errors.New("error: node not found")
+
+// MethodNotAllowedError 路径匹配但方法未注册，Allow 为该路径支持的方法
+type MethodNotAllowedError struct {
+	Allow []string
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	return "405, method is not supported"
+}
+
+// anyMethods 是 Any 注册的方法集合
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
 type Router struct {
 	trie *Trie
 }
@@ -8,16 +31,24 @@ func NewRouter() *Router {
 	return &Router{trie: NewTrie()}
 }
 
-func (r Router) AddRouter(method, path string, handler HandlerFunc) {
-	r.trie.Insert(path, handler, &Options{
-		"Method": method,
-	})
+// AddRouter 注册的 handlers 是已拼好中间件的完整调用链，末尾的可选段会展开成多条路由
+func (r *Router) AddRouter(method, path string, handlers []HandlerFunc) {
+	for _, pattern := range expandOptional(path) {
+		r.trie.Insert(method, pattern, handlers)
+	}
 }
 
-func (r Router) Search(method, path string) (HandlerFunc, error) {
-	handler, err := r.trie.Search(method, path)
-	if err != nil {
-		return handler, err
+// Search 把路由参数追加到 params 中，返回匹配方法的调用链和注册时的路由模式
+func (r *Router) Search(method, path string, params *Params) ([]HandlerFunc, string, error) {
+	if n := r.trie.Search(method, path, params); n != nil {
+		return n.handlers[method], n.pattern, nil
+	}
+	allow, pattern := r.trie.Allowed(path)
+	if allow == nil {
+		return nil, "", ErrNotFound
+	}
+	if !slices.Contains(allow, http.MethodOptions) {
+		allow = append(allow, http.MethodOptions)
 	}
-	return handler, nil
+	return nil, pattern, &MethodNotAllowedError{Allow: allow}
 }
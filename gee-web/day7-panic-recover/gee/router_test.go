@@ -0,0 +1,232 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newTestRouter() *Router {
+	r := NewRouter()
+	r.AddRouter("GET", "/", nil)
+	r.AddRouter("GET", "/hello/:name", nil)
+	r.AddRouter("POST", "/hello/:name", nil)
+	r.AddRouter("GET", "/assets/*filepath", nil)
+	return r
+}
+
+func TestSearchMethod(t *testing.T) {
+	r := newTestRouter()
+	var params Params
+	_, _, err := r.Search("GET", "/hello/geektutu", &params)
+	if err != nil || params.ByName("name") != "geektutu" {
+		t.Fatal("GET /hello/geektutu should be matched")
+	}
+	if _, _, err := r.Search("POST", "/hello/geektutu", new(Params)); err != nil {
+		t.Fatal("POST /hello/geektutu should not be overwritten by GET")
+	}
+	if _, _, err := r.Search("GET", "/nothing", new(Params)); err != ErrNotFound {
+		t.Fatal("GET /nothing should be not found")
+	}
+
+	_, _, err = r.Search("DELETE", "/hello/geektutu", new(Params))
+	var notAllowed *MethodNotAllowedError
+	if !errors.As(err, &notAllowed) {
+		t.Fatal("DELETE /hello/geektutu should be method not allowed")
+	}
+	if !reflect.DeepEqual(notAllowed.Allow, []string{"GET", "POST", "OPTIONS"}) {
+		t.Fatalf("unexpected allow methods %v", notAllowed.Allow)
+	}
+}
+
+func TestSearchMethodFallthrough(t *testing.T) {
+	r := NewRouter()
+	r.AddRouter("POST", "/user/:id", []HandlerFunc{nil})
+	r.AddRouter("GET", "/user/*rest", []HandlerFunc{nil, nil})
+
+	// :id 没有注册 GET，继续回溯到 *rest
+	params := Params{{"subdomain", "api"}}
+	handlers, pattern, err := r.Search("GET", "/user/1", &params)
+	if err != nil || len(handlers) != 2 || pattern != "/user/*rest" ||
+		!reflect.DeepEqual(params, Params{{"subdomain", "api"}, {"rest", "1"}}) {
+		t.Fatalf("GET /user/1 should reach the catch-all, got %s %v %v", pattern, params, err)
+	}
+	if _, pattern, err := r.Search("POST", "/user/1", new(Params)); err != nil || pattern != "/user/:id" {
+		t.Fatalf("POST /user/1 should match /user/:id, got %s %v", pattern, err)
+	}
+
+	// 所有匹配的节点都不支持时，Allow 是它们的方法的并集
+	params = nil
+	_, _, err = r.Search("DELETE", "/user/1", &params)
+	var notAllowed *MethodNotAllowedError
+	if !errors.As(err, &notAllowed) || !reflect.DeepEqual(notAllowed.Allow, []string{"GET", "POST", "OPTIONS"}) || len(params) != 0 {
+		t.Fatalf("DELETE /user/1 should be method not allowed, got %v %v", err, params)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	r := New()
+	r.GET("/user", func(c *Context) {})
+	r.PUT("/user", func(c *Context) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/user", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status should be 405, got %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, PUT, OPTIONS" {
+		t.Fatalf("unexpected Allow header %q", allow)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/user", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, PUT, OPTIONS" {
+		t.Fatalf("OPTIONS should be answered automatically, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestAny(t *testing.T) {
+	r := New()
+	r.Any("/any", func(c *Context) {
+		c.String(http.StatusOK, c.Req.Method)
+	})
+	for _, method := range anyMethods {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/any", nil))
+		if w.Code != http.StatusOK || w.Body.String() != method {
+			t.Fatalf("%s /any should be handled, got %d %q", method, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestSearchParams(t *testing.T) {
+	r := newTestRouter()
+	r.AddRouter("GET", "/hello/b/c", nil)
+	cases := []struct {
+		path   string
+		params Params
+	}{
+		{"/", Params{}},
+		{"/hello/b", Params{{"name", "b"}}},
+		{"/hello/b/c", Params{}},
+		{"/assets/css/test.css", Params{{"filepath", "css/test.css"}}},
+	}
+	for _, tc := range cases {
+		params := Params{}
+		if _, _, err := r.Search("GET", tc.path, &params); err != nil {
+			t.Fatalf("%s should be matched, got %v", tc.path, err)
+		}
+		if !reflect.DeepEqual(params, tc.params) {
+			t.Fatalf("%s should have params %v, got %v", tc.path, tc.params, params)
+		}
+	}
+	if _, _, err := r.Search("GET", "/hello/", new(Params)); err != ErrNotFound {
+		t.Fatal("empty segment should not match :name")
+	}
+}
+
+// discardWriter 是不记录任何内容的 ResponseWriter，避免基准测试统计到它的分配
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func benchmarkServe(b *testing.B, pattern, path string) {
+	r := New()
+	r.Use(func(c *Context) { c.Next() })
+	r.GET(pattern, func(c *Context) {})
+	req := httptest.NewRequest("GET", path, nil)
+	w := &discardWriter{header: http.Header{}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkServeStatic(b *testing.B) {
+	benchmarkServe(b, "/user/profile/settings", "/user/profile/settings")
+}
+
+func BenchmarkServeParam(b *testing.B) {
+	benchmarkServe(b, "/user/:id/posts/:post", "/user/42/posts/7")
+}
+
+func BenchmarkServeWildcard(b *testing.B) {
+	benchmarkServe(b, "/assets/*filepath", "/assets/css/geektutu.css")
+}
+
+func TestInsertConflict(t *testing.T) {
+	cases := []struct {
+		exist, path string
+	}{
+		{"/user/:id", "/user/:name"},
+		{"/user/:id/profile", "/user/:name/posts"},
+		{"/assets/*filepath", "/assets/*file"},
+		{"/assets/*filepath", "/assets/*filepath"},
+		{"/hello", "/*filepath/hello"},
+	}
+	for _, tc := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s should conflict with %s", tc.path, tc.exist)
+				}
+			}()
+			r := NewRouter()
+			r.AddRouter("GET", tc.exist, nil)
+			r.AddRouter("GET", tc.path, nil)
+		}()
+	}
+}
+
+func TestSearchPriority(t *testing.T) {
+	r := NewRouter()
+	r.AddRouter("GET", "/user/*path", []HandlerFunc{nil, nil, nil})
+	r.AddRouter("GET", "/user/:id", []HandlerFunc{nil, nil})
+	r.AddRouter("GET", "/user/new", []HandlerFunc{nil})
+	for path, want := range map[string]int{
+		"/user/new":   1,
+		"/user/42":    2,
+		"/user/42/ok": 3,
+	} {
+		// 多次查找结果必须一致
+		for i := 0; i < 10; i++ {
+			handlers, _, _ := r.Search("GET", path, new(Params))
+			if len(handlers) != want {
+				t.Fatalf("%s matched wrong route", path)
+			}
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.POST("/user/:id", namedHandler)
+	r.GET("/user/:id", namedHandler)
+	r.GET("/user/new", func(c *Context) {})
+	r.GET("/", namedHandler)
+
+	var got []string
+	for _, route := range r.Routes() {
+		got = append(got, route.Method+" "+route.Path+" "+route.Handler)
+	}
+	want := []string{
+		"GET / gee.namedHandler",
+		"GET /user/new gee.TestRoutes.func1",
+		"GET /user/:id gee.namedHandler",
+		"POST /user/:id gee.namedHandler",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected routes %v", got)
+	}
+}
+
+// namedHandler 是 TestRoutes 使用的具名处理函数
+func namedHandler(c *Context) {}
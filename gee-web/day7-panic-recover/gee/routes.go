@@ -0,0 +1,62 @@
+package gee
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// RouteInfo 描述一条已注册的路由，Handler 是处理函数的名字，Host 为空表示 Engine 本身的路由
+type RouteInfo struct {
+	Host        string
+	Method      string
+	Path        string
+	Handler     string
+	HandlerFunc HandlerFunc
+}
+
+// Routes 返回所有已注册的路由，顺序与匹配优先级一致，同一路径下按方法名排序。
+// Engine 本身的路由在前，之后是按主机名排序的各主机的路由
+func (engine *Engine) Routes() []RouteInfo {
+	routes := engine.routers.routes("", nil)
+	hosts := make([]string, 0, len(engine.hosts))
+	for pattern := range engine.hosts {
+		hosts = append(hosts, pattern)
+	}
+	sort.Strings(hosts)
+	for _, pattern := range hosts {
+		routes = engine.hosts[pattern].router.routes(pattern, routes)
+	}
+	return routes
+}
+
+func (r *Router) routes(host string, routes []RouteInfo) []RouteInfo {
+	if routes == nil {
+		routes = make([]RouteInfo, 0)
+	}
+	r.trie.root.walk(func(n *node) {
+		for _, method := range n.allowed() {
+			handlers := n.handlers[method]
+			var last HandlerFunc
+			if len(handlers) > 0 {
+				last = handlers[len(handlers)-1]
+			}
+			routes = append(routes, RouteInfo{
+				Host:        host,
+				Method:      method,
+				Path:        n.pattern,
+				Handler:     nameOfFunction(last),
+				HandlerFunc: last,
+			})
+		}
+	})
+	return routes
+}
+
+func nameOfFunction(f any) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
+}
@@ -0,0 +1,115 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Run 系列方法在 Shutdown 后返回 nil，其余情况返回监听或服务的错误
+
+func (engine *Engine) Run(addr string) error {
+	srv := engine.newServer(addr)
+	return engine.serve(srv, srv.ListenAndServe)
+}
+
+func (engine *Engine) RunTLS(addr, certFile, keyFile string) error {
+	srv := engine.newServer(addr)
+	return engine.serve(srv, func() error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// RunUnix 监听 unix socket，残留的 socket 文件会先被删除
+func (engine *Engine) RunUnix(file string) error {
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	listener, err := net.Listen("unix", file)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file)
+	return engine.RunListener(listener)
+}
+
+func (engine *Engine) RunListener(listener net.Listener) error {
+	srv := engine.newServer(listener.Addr().String())
+	return engine.serve(srv, func() error {
+		return srv.Serve(listener)
+	})
+}
+
+// OnShutdown 注册退出钩子，在 Shutdown 等待请求结束后按注册顺序执行
+func (engine *Engine) OnShutdown(hook func()) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	engine.onShutdown = append(engine.onShutdown, hook)
+}
+
+// Shutdown 停止所有 Server 接收新连接，等待进行中的调用链结束后执行退出钩子。
+// ctx 到期时不再等待，返回 ctx.Err()，钩子仍会执行
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.mu.Lock()
+	servers, hooks := engine.servers, engine.onShutdown
+	engine.servers = nil
+	engine.mu.Unlock()
+
+	var err error
+	for _, srv := range servers {
+		if e := srv.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	// 被 Hijack 的连接不受 Server 管理，需要单独等待
+	if e := engine.waitIdle(ctx); e != nil && err == nil {
+		err = e
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+	return err
+}
+
+func (engine *Engine) waitIdle(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for engine.active.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (engine *Engine) newServer(addr string) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: engine}
+	engine.mu.Lock()
+	engine.servers = append(engine.servers, srv)
+	engine.mu.Unlock()
+	return srv
+}
+
+func (engine *Engine) serve(srv *http.Server, serve func() error) error {
+	if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+		engine.removeServer(srv)
+		return err
+	}
+	return nil
+}
+
+func (engine *Engine) removeServer(srv *http.Server) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	for i, s := range engine.servers {
+		if s == srv {
+			engine.servers = append(engine.servers[:i], engine.servers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sessions
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte("o"), 32), bytes.Repeat([]byte("n"), 32)
+	encrypted, err := NewEncryptedCodec(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, _ := NewEncryptedCodec(newKey, oldKey)
+	retired, _ := NewEncryptedCodec(newKey)
+	codecs := map[string][3]Codec{
+		"signed":    {NewSignedCodec(oldKey), NewSignedCodec(newKey, oldKey), NewSignedCodec(newKey)},
+		"encrypted": {encrypted, rotated, retired},
+	}
+	for name, codec := range codecs {
+		old, current := codec[0], codec[1]
+		value, err := old.Encode("session", []byte("gee"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := current.Decode("session", value); err != nil || string(b) != "gee" {
+			t.Fatalf("%s: values signed with an old key should still decode, got %q %v", name, b, err)
+		}
+		if _, err := current.Decode("other", value); err != ErrInvalidCookie {
+			t.Fatalf("%s: value should be bound to the cookie name", name)
+		}
+		tampered := value[:len(value)-2] + strings.Repeat("A", 2)
+		if _, err := current.Decode("session", tampered); err != ErrInvalidCookie {
+			t.Fatalf("%s: tampered value should be rejected", name)
+		}
+		if _, err := codec[2].Decode("session", value); err != ErrInvalidCookie {
+			t.Fatalf("%s: value should be rejected after the old key is retired", name)
+		}
+	}
+	if strings.Contains(must(encrypted.Encode("session", []byte("secret"))), "c2VjcmV0") {
+		t.Fatal("encrypted codec should hide the value")
+	}
+	if _, err := NewEncryptedCodec([]byte("short")); err == nil {
+		t.Fatal("invalid AES key should be rejected")
+	}
+}
+
+func must(s string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
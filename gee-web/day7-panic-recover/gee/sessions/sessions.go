@@ -0,0 +1,161 @@
+package sessions
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log"
+	"net/http"
+
+	"gee"
+)
+
+// sessionsKey 是 Sessions 中间件在 Context 中保存会话的 key
+const sessionsKey = "gee.sessions"
+
+// flashKey 是闪存消息在 Values 中的 key
+const flashKey = "_flash"
+
+// Options 是写出会话 cookie 时的属性，MaxAge 单位为秒，小于 0 表示删除会话
+type Options struct {
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// DefaultOptions 返回 30 天有效、HttpOnly、SameSite=Lax 的选项，HTTPS 请求会自动加上 Secure
+func DefaultOptions() Options {
+	return Options{Path: "/", MaxAge: 30 * 24 * 3600, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+}
+
+// Store 负责加载和保存会话。Load 在没有会话或会话无效时返回新的会话
+type Store interface {
+	Load(c *gee.Context, name string) (*Session, error)
+	Save(c *gee.Context, s *Session) error
+}
+
+// Session 是一次请求中的会话，只能在处理函数中使用
+type Session struct {
+	// ID 由服务端存储使用，cookie 存储中为空
+	ID     string
+	Values map[string]any
+	// Options 可以在 Save 之前修改，例如把 MaxAge 设为 -1 以删除会话
+	Options Options
+	IsNew   bool
+	name    string
+	store   Store
+	ctx     *gee.Context
+}
+
+// NewSession 创建新的会话，供 Store 的实现使用
+func NewSession(store Store, c *gee.Context, name string, options Options) *Session {
+	return &Session{Values: make(map[string]any), Options: options, IsNew: true, name: name, store: store, ctx: c}
+}
+
+func (s *Session) Name() string {
+	return s.name
+}
+
+func (s *Session) Get(key string) any {
+	return s.Values[key]
+}
+
+func (s *Session) Set(key string, value any) {
+	s.Values[key] = value
+}
+
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+}
+
+// Clear 删除所有值
+func (s *Session) Clear() {
+	for key := range s.Values {
+		delete(s.Values, key)
+	}
+}
+
+// Flash 添加一条闪存消息，它在下一次被 Flashes 读取后删除
+func (s *Session) Flash(value any) {
+	flashes, _ := s.Values[flashKey].([]any)
+	s.Values[flashKey] = append(flashes, value)
+}
+
+// Flashes 返回并删除所有闪存消息，需要 Save 后删除才会生效
+func (s *Session) Flashes() []any {
+	flashes, _ := s.Values[flashKey].([]any)
+	delete(s.Values, flashKey)
+	return flashes
+}
+
+// Save 保存会话并写出 cookie，必须在写响应体之前调用
+func (s *Session) Save() error {
+	return s.store.Save(s.ctx, s)
+}
+
+// Sessions 为请求提供名为 name 的会话，会话在第一次调用 Default 时才加载
+func Sessions(name string, store Store) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		c.Set(sessionsKey, &lazySession{name: name, store: store})
+		c.Next()
+	}
+}
+
+type lazySession struct {
+	name    string
+	store   Store
+	session *Session
+}
+
+// Default 返回 Sessions 中间件为当前请求提供的会话。加载失败时记录日志并返回新的会话
+func Default(c *gee.Context) *Session {
+	lazy := c.MustGet(sessionsKey).(*lazySession)
+	if lazy.session == nil {
+		s, err := lazy.store.Load(c, lazy.name)
+		if err != nil {
+			log.Printf("sessions: load %s: %v", lazy.name, err)
+		}
+		if s == nil {
+			s = NewSession(lazy.store, c, lazy.name, DefaultOptions())
+		}
+		lazy.session = s
+	}
+	return lazy.session
+}
+
+func init() {
+	gob.Register([]any(nil))
+}
+
+// gobEncode 用 gob 编码会话的值，放入会话的自定义类型需要先 gob.Register
+func gobEncode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobDecode(b []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// setCookie 按会话的 Options 写出 cookie
+func setCookie(c *gee.Context, s *Session, value string) {
+	options := s.Options
+	if options.MaxAge < 0 {
+		value = ""
+	}
+	c.SetRawCookie(&http.Cookie{
+		Name:     s.name,
+		Value:    value,
+		Path:     options.Path,
+		Domain:   options.Domain,
+		MaxAge:   options.MaxAge,
+		Secure:   options.Secure || c.IsTLS(),
+		HttpOnly: options.HttpOnly,
+		SameSite: options.SameSite,
+	})
+}
@@ -0,0 +1,93 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gee"
+)
+
+func newTestEngine(store Store) *gee.Engine {
+	r := gee.New()
+	r.Use(Sessions("gee_session", store))
+	r.POST("/login", func(c *gee.Context) {
+		s := Default(c)
+		s.Set("user", "geektutu")
+		s.Flash("welcome")
+		if err := s.Save(); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/me", func(c *gee.Context) {
+		s := Default(c)
+		user, _ := s.Get("user").(string)
+		flashes := s.Flashes()
+		s.Save()
+		c.JSON(http.StatusOK, gee.H{"user": user, "flashes": len(flashes)})
+	})
+	r.POST("/logout", func(c *gee.Context) {
+		s := Default(c)
+		s.Options.MaxAge = -1
+		s.Save()
+	})
+	return r
+}
+
+// do 发送请求，并像浏览器一样保存响应中的 cookie
+func do(r *gee.Engine, jar map[string]*http.Cookie, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for _, cookie := range jar {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(jar, cookie.Name)
+		} else {
+			jar[cookie.Name] = cookie
+		}
+	}
+	return w
+}
+
+func TestSessionStores(t *testing.T) {
+	codec, _ := NewEncryptedCodec(make([]byte, 32))
+	stores := map[string]Store{
+		"cookie": NewCookieStore(codec),
+		"memory": NewMemoryStore(),
+		"cache":  NewCacheStore(1 << 20),
+	}
+	for name, store := range stores {
+		r := newTestEngine(store)
+		jar := map[string]*http.Cookie{}
+		if w := do(r, jar, "GET", "/me"); w.Body.String() != `{"flashes":0,"user":""}` {
+			t.Fatalf("%s: new session should be empty, got %s", name, w.Body.String())
+		}
+		do(r, jar, "POST", "/login")
+		cookie := jar["gee_session"]
+		if cookie == nil || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+			t.Fatalf("%s: session cookie should use secure defaults, got %v", name, cookie)
+		}
+		if w := do(r, jar, "GET", "/me"); w.Body.String() != `{"flashes":1,"user":"geektutu"}` {
+			t.Fatalf("%s: unexpected session %s", name, w.Body.String())
+		}
+		if w := do(r, jar, "GET", "/me"); w.Body.String() != `{"flashes":0,"user":"geektutu"}` {
+			t.Fatalf("%s: flashes should be consumed, got %s", name, w.Body.String())
+		}
+		stale := *jar["gee_session"]
+		do(r, jar, "POST", "/logout")
+		if len(jar) != 0 {
+			t.Fatalf("%s: logout should delete the cookie", name)
+		}
+		if name != "cookie" {
+			jar["gee_session"] = &stale
+			if w := do(r, jar, "GET", "/me"); w.Body.String() != `{"flashes":0,"user":""}` {
+				t.Fatalf("%s: server-side session should be destroyed, got %s", name, w.Body.String())
+			}
+		}
+	}
+}
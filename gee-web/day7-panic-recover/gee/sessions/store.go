@@ -0,0 +1,241 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"gee"
+
+	"geecache/lru"
+)
+
+// maxCookieSize 是浏览器普遍支持的单个 cookie 的大小上限
+const maxCookieSize = 4096
+
+var errCookieTooLarge = errors.New("sessions: encoded session exceeds 4096 bytes")
+
+// CookieStore 把会话的全部内容编码后保存在 cookie 中，服务端不保存状态
+type CookieStore struct {
+	Codec   Codec
+	Options Options
+}
+
+func NewCookieStore(codec Codec) *CookieStore {
+	return &CookieStore{Codec: codec, Options: DefaultOptions()}
+}
+
+// cookiePayload 带上过期时间，被盗的 cookie 不会永久有效
+type cookiePayload struct {
+	Values  map[string]any
+	Expires int64
+}
+
+func (s *CookieStore) Load(c *gee.Context, name string) (*Session, error) {
+	session := NewSession(s, c, name, s.Options)
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	b, err := s.Codec.Decode(name, cookie.Value)
+	if err != nil {
+		return session, err
+	}
+	var payload cookiePayload
+	if err := gobDecode(b, &payload); err != nil {
+		return session, err
+	}
+	if payload.Expires != 0 && time.Now().Unix() > payload.Expires {
+		return session, nil
+	}
+	if payload.Values != nil {
+		session.Values = payload.Values
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *CookieStore) Save(c *gee.Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		setCookie(c, session, "")
+		return nil
+	}
+	payload := cookiePayload{Values: session.Values}
+	if session.Options.MaxAge > 0 {
+		payload.Expires = time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second).Unix()
+	}
+	b, err := gobEncode(payload)
+	if err != nil {
+		return err
+	}
+	value, err := s.Codec.Encode(session.name, b)
+	if err != nil {
+		return err
+	}
+	if len(value) > maxCookieSize {
+		return errCookieTooLarge
+	}
+	setCookie(c, session, value)
+	return nil
+}
+
+// backend 保存服务端会话的数据
+type backend interface {
+	load(id string, now time.Time) ([]byte, bool)
+	save(id string, data []byte, expire time.Time)
+	delete(id string)
+}
+
+// ServerStore 把会话保存在服务端，cookie 中只有随机生成的会话 ID
+type ServerStore struct {
+	Options Options
+	backend backend
+}
+
+// NewMemoryStore 返回保存在进程内存中的存储，过期的会话会被定期清理
+func NewMemoryStore() *ServerStore {
+	return &ServerStore{Options: DefaultOptions(), backend: &memoryBackend{entries: make(map[string]memoryEntry)}}
+}
+
+// NewCacheStore 返回基于 geecache LRU 缓存的存储，总大小不超过 maxBytes，超出时淘汰最久未访问的会话
+func NewCacheStore(maxBytes int64) *ServerStore {
+	return &ServerStore{Options: DefaultOptions(), backend: &cacheBackend{cache: lru.New(maxBytes, nil)}}
+}
+
+func (s *ServerStore) Load(c *gee.Context, name string) (*Session, error) {
+	session := NewSession(s, c, name, s.Options)
+	cookie, err := c.Req.Cookie(name)
+	if err != nil || !validID(cookie.Value) {
+		return session, nil
+	}
+	b, ok := s.backend.load(cookie.Value, time.Now())
+	if !ok {
+		return session, nil
+	}
+	values := make(map[string]any)
+	if err := gobDecode(b, &values); err != nil {
+		return session, err
+	}
+	session.ID, session.Values, session.IsNew = cookie.Value, values, false
+	return session, nil
+}
+
+func (s *ServerStore) Save(c *gee.Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			s.backend.delete(session.ID)
+		}
+		setCookie(c, session, "")
+		return nil
+	}
+	b, err := gobEncode(session.Values)
+	if err != nil {
+		return err
+	}
+	if session.ID == "" {
+		if session.ID, err = newID(); err != nil {
+			return err
+		}
+	}
+	// MaxAge 为 0 表示浏览器会话，服务端保留一天
+	ttl := 24 * time.Hour
+	if session.Options.MaxAge > 0 {
+		ttl = time.Duration(session.Options.MaxAge) * time.Second
+	}
+	s.backend.save(session.ID, b, time.Now().Add(ttl))
+	setCookie(c, session, session.ID)
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validID(id string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil && len(b) == 32
+}
+
+type memoryEntry struct {
+	data   []byte
+	expire time.Time
+}
+
+type memoryBackend struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	sweepAt time.Time
+}
+
+func (m *memoryBackend) load(id string, now time.Time) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.After(m.sweepAt) {
+		for k, e := range m.entries {
+			if now.After(e.expire) {
+				delete(m.entries, k)
+			}
+		}
+		m.sweepAt = now.Add(time.Minute)
+	}
+	e, ok := m.entries[id]
+	if !ok || now.After(e.expire) {
+		return nil, false
+	}
+	return e.data, true
+}
+
+func (m *memoryBackend) save(id string, data []byte, expire time.Time) {
+	m.mu.Lock()
+	m.entries[id] = memoryEntry{data: data, expire: expire}
+	m.mu.Unlock()
+}
+
+func (m *memoryBackend) delete(id string) {
+	m.mu.Lock()
+	delete(m.entries, id)
+	m.mu.Unlock()
+}
+
+type cacheEntry memoryEntry
+
+// Len 实现 lru.Value
+func (e *cacheEntry) Len() int {
+	return len(e.data)
+}
+
+type cacheBackend struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+func (b *cacheBackend) load(id string, now time.Time) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.cache.Get(id)
+	if !ok || now.After(v.(*cacheEntry).expire) {
+		return nil, false
+	}
+	return v.(*cacheEntry).data, true
+}
+
+func (b *cacheBackend) save(id string, data []byte, expire time.Time) {
+	b.mu.Lock()
+	b.cache.Add(id, &cacheEntry{data: data, expire: expire})
+	b.mu.Unlock()
+}
+
+// delete 让条目立即过期，geecache 的 LRU 没有删除操作，条目会在之后被淘汰
+func (b *cacheBackend) delete(id string) {
+	b.mu.Lock()
+	if v, ok := b.cache.Get(id); ok {
+		v.(*cacheEntry).expire = time.Time{}
+	}
+	b.mu.Unlock()
+}
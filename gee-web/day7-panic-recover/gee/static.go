@@ -0,0 +1,181 @@
+package gee
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig 配置静态文件服务
+type StaticConfig struct {
+	// Index 是目录请求返回的文件，默认为 index.html。目录本身不会被列出
+	Index string
+	// SPA 为 true 时，找不到的文件返回根目录的 Index，交给前端路由处理
+	SPA bool
+	// Precompressed 为 true 时，客户端支持的话优先返回同名的 .br 或 .gz 文件
+	Precompressed bool
+	// MaxAge 大于 0 时设置 Cache-Control: public, max-age
+	MaxAge time.Duration
+}
+
+// Static 把磁盘上的 root 目录映射到 relativePath 下
+func (r *RouterGroup) Static(relativePath, root string) {
+	r.StaticFS(relativePath, os.DirFS(root))
+}
+
+// StaticFS 从 fsys（例如 embed.FS）提供静态文件，请求路径会先被清理，无法访问 fsys 之外的文件。
+// 响应带有根据内容计算的 ETag，条件请求和 Range 由 http.ServeContent 处理
+func (r *RouterGroup) StaticFS(relativePath string, fsys fs.FS, config ...StaticConfig) {
+	s := &staticFS{fsys: fsys, group: r}
+	if len(config) > 0 {
+		s.config = config[0]
+	}
+	if s.config.Index == "" {
+		s.config.Index = "index.html"
+	}
+	pattern := strings.TrimSuffix(relativePath, "/") + "/*filepath"
+	r.GET(pattern, s.serve)
+	r.HEAD(pattern, s.serve)
+}
+
+type staticFS struct {
+	fsys fs.FS
+	// group 是注册的分组，找不到文件时从这里交给 NoRoute
+	group  *RouterGroup
+	config StaticConfig
+	// etags 缓存文件内容的摘要，key 包含修改时间和大小，文件变化后自动失效
+	etags sync.Map
+}
+
+// precompressed 按优先级排列
+var precompressed = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+func (s *staticFS) serve(c *Context) {
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	if name == "" {
+		name = "."
+	}
+	name, info, err := s.resolve(name)
+	if err != nil && s.config.SPA {
+		name, info, err = s.resolve(".")
+	}
+	if err != nil {
+		c.notFound(s.group)
+		return
+	}
+
+	header := c.Writer.Header()
+	if s.config.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+		accept := c.GetHeader("Accept-Encoding")
+		for _, p := range precompressed {
+			if !acceptsEncoding(accept, p.encoding) {
+				continue
+			}
+			if cinfo, err := fs.Stat(s.fsys, name+p.ext); err == nil && cinfo.Mode().IsRegular() {
+				if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+					header.Set("Content-Type", ctype)
+				} else {
+					header.Set("Content-Type", "application/octet-stream")
+				}
+				header.Set("Content-Encoding", p.encoding)
+				name, info = name+p.ext, cinfo
+				break
+			}
+		}
+	}
+	if err := s.serveFile(c, name, info); err != nil {
+		panic(err)
+	}
+}
+
+// resolve 返回要发送的文件，目录会换成其中的 Index
+func (s *staticFS) resolve(name string) (string, fs.FileInfo, error) {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return "", nil, err
+	}
+	if info.IsDir() {
+		name = path.Join(name, s.config.Index)
+		if info, err = fs.Stat(s.fsys, name); err != nil {
+			return "", nil, err
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return "", nil, fs.ErrNotExist
+	}
+	return name, info, nil
+}
+
+func (s *staticFS) serveFile(c *Context, name string, info fs.FileInfo) error {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(b)
+	}
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		return err
+	}
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	if s.config.MaxAge > 0 {
+		header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(s.config.MaxAge.Seconds())))
+	}
+	http.ServeContent(c.Writer, c.Req, name, info.ModTime(), content)
+	return nil
+}
+
+func (s *staticFS) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := name + "\x00" + strconv.FormatInt(info.ModTime().UnixNano(), 10) + "\x00" + strconv.FormatInt(info.Size(), 10)
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(key, etag)
+	return etag, nil
+}
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受 encoding，q=0 表示拒绝
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && key == "q" {
+			q, err := strconv.ParseFloat(value, 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStaticFS(t *testing.T) {
+	modTime := time.Date(2020, 1, 9, 1, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>gee</h1>"), ModTime: modTime},
+		"app.js":         {Data: []byte("console.log('gee')"), ModTime: modTime},
+		"app.js.gz":      {Data: []byte("gzipped"), ModTime: modTime},
+		"docs/guide.txt": {Data: []byte("0123456789"), ModTime: modTime},
+	}
+	r := New()
+	r.StaticFS("/assets", fsys, StaticConfig{SPA: true, Precompressed: true, MaxAge: time.Hour})
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/assets/app.js")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "console.log('gee')" || etag == "" ||
+		w.Header().Get("Cache-Control") != "public, max-age=3600" {
+		t.Fatalf("unexpected response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if w := get("/assets/app.js", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("matching ETag should return 304, got %d", w.Code)
+	}
+	if w := get("/assets/app.js", "If-Modified-Since", modTime.Format(http.TimeFormat)); w.Code != http.StatusNotModified {
+		t.Fatalf("unmodified file should return 304, got %d", w.Code)
+	}
+	w = get("/assets/app.js", "Accept-Encoding", "br;q=0, gzip")
+	if w.Body.String() != "gzipped" || w.Header().Get("Content-Encoding") != "gzip" ||
+		w.Header().Get("Content-Type") != "text/javascript; charset=utf-8" {
+		t.Fatalf("precompressed sibling should be served, got %q %v", w.Body.String(), w.Header())
+	}
+	if w := get("/assets/docs/guide.txt", "Range", "bytes=2-4"); w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Fatalf("range request should be honored, got %d %q", w.Code, w.Body.String())
+	}
+	// 目录不会被列出，SPA 模式下未知路径返回 index.html
+	for _, path := range []string{"/assets/", "/assets/docs", "/assets/user/1", "/assets/../../etc/passwd"} {
+		if w := get(path); w.Code != http.StatusOK || w.Body.String() != "<h1>gee</h1>" {
+			t.Fatalf("%s should fall back to index.html, got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStaticNotFound(t *testing.T) {
+	r := New()
+	r.Static("/files", t.TempDir())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/missing.txt", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing file should be 404, got %d", w.Code)
+	}
+}
+
+func TestStaticNoRoute(t *testing.T) {
+	var marks []string
+	mark := func(s string) HandlerFunc {
+		return func(c *Context) {
+			marks = append(marks, s)
+			c.Next()
+		}
+	}
+	r := New()
+	r.Use(mark("global"))
+	r.NoRoute(func(c *Context) { c.String(http.StatusNotFound, "root 404") })
+	r.Static("/files", t.TempDir())
+	assets := r.Group("/assets")
+	assets.Use(mark("assets"))
+	assets.Static("/", t.TempDir())
+	missing := r.Group("/assets")
+	missing.Use(mark("missing"))
+	missing.NoRoute(func(c *Context) { c.String(http.StatusNotFound, "asset 404") })
+
+	tests := []struct {
+		path, body string
+		marks      []string
+	}{
+		{"/files/a.txt", "root 404", []string{"global"}},
+		{"/assets/a.png", "asset 404", []string{"global", "assets", "missing"}},
+	}
+	for _, tt := range tests {
+		marks = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != http.StatusNotFound || w.Body.String() != tt.body || !reflect.DeepEqual(marks, tt.marks) {
+			t.Fatalf("%s: expect %q after %v, got %d %q after %v", tt.path, tt.body, tt.marks, w.Code, w.Body.String(), marks)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package gee
+
+import (
+	"io"
+
+	"gee/render"
+)
+
+// Flush 把已写入的数据立即发送给客户端
+func (c *Context) Flush() {
+	c.Writer.Flush()
+}
+
+// Stream 反复调用 step 并在每次调用后 flush，step 返回 false 时结束。
+// 客户端断开时提前结束并返回 true
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.Writer)
+			c.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// SSEvent 发送一条 Server-Sent Event，并自动设置 text/event-stream 响应头
+func (c *Context) SSEvent(name string, data any) {
+	c.Render(-1, render.SSEvent{Event: name, Data: data})
+	c.Flush()
+}
@@ -0,0 +1,48 @@
+package gee
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/events", nil))
+	c.SSEvent("message", "hello\nworld")
+	c.SSEvent("progress", H{"percent": 50})
+
+	want := "event: message\ndata: hello\ndata: world\n\nevent: progress\ndata: {\"percent\":50}\n\n"
+	if w.Body.String() != want {
+		t.Fatalf("unexpected event stream %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" || !w.Flushed {
+		t.Fatal("event stream should set Content-Type and flush")
+	}
+}
+
+func TestStreamClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/stream", nil).WithContext(ctx))
+
+	i := 0
+	clientGone := c.Stream(func(w io.Writer) bool {
+		i++
+		fmt.Fprintf(w, "%d;", i)
+		if i == 3 {
+			cancel()
+		}
+		return true
+	})
+	if !clientGone || w.Body.String() != "1;2;3;" {
+		t.Fatalf("stream should stop when client is gone, got %v %q", clientGone, w.Body.String())
+	}
+
+	c = NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/stream", nil))
+	if c.Stream(func(w io.Writer) bool { return false }) {
+		t.Fatal("stream should end normally when step returns false")
+	}
+}
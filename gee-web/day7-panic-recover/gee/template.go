@@ -0,0 +1,232 @@
+package gee
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HTMLConfig 配置 HTML 模板的加载方式。
+// 每个页面单独编译为 Layout + Partials + 页面文件，页面用 {{define}} 覆盖布局中的 {{block}}
+type HTMLConfig struct {
+	// FS 为空时从操作系统的文件系统读取，可以传入 embed.FS
+	FS fs.FS
+	// Layout 是基础布局文件，为空时直接执行页面文件本身
+	Layout string
+	// Partials 是所有页面共享的模板文件的 glob
+	Partials []string
+	// Pages 是页面文件的 glob，页面名为文件的路径，例如 pages/index.html
+	Pages []string
+	// FuncMap 为空时使用 Engine.SetFuncMap 设置的函数。
+	// 渲染时传入的函数必须在这里先声明同名的占位函数，否则解析会失败
+	FuncMap template.FuncMap
+	// Debug 为 true 时每次渲染前检查文件是否变化，变化后重新加载
+	Debug bool
+}
+
+// HTMLTemplates 保存编译好的页面，Debug 模式下会在文件变化后自动重新加载
+type HTMLTemplates struct {
+	config HTMLConfig
+	mu     sync.RWMutex
+	// shared 包含 Layout 和 Partials，按模板名查找不属于任何页面的模板
+	shared *htmlPage
+	pages  map[string]*htmlPage
+	// stamp 记录加载时所有文件的修改时间和大小
+	stamp string
+}
+
+type htmlPage struct {
+	// master 从不执行，用于带额外函数渲染时 Clone，执行过的模板不能再 Clone
+	master *template.Template
+	exec   *template.Template
+	entry  string
+}
+
+func NewHTMLTemplates(config HTMLConfig) (*HTMLTemplates, error) {
+	t := &HTMLTemplates{config: config}
+	files, err := t.files()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.load(files); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Lookup 返回页面或共享模板，以及执行时应使用的模板名
+func (t *HTMLTemplates) Lookup(name string, funcs ...template.FuncMap) (*template.Template, string, error) {
+	if t.config.Debug {
+		if err := t.reload(); err != nil {
+			return nil, "", err
+		}
+	}
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	shared := t.shared
+	t.mu.RUnlock()
+	if !ok {
+		if shared.master.Lookup(name) == nil {
+			return nil, "", fmt.Errorf("gee: html template %q is undefined", name)
+		}
+		page = &htmlPage{master: shared.master, exec: shared.exec, entry: name}
+	}
+	if len(funcs) == 0 {
+		return page.exec, page.entry, nil
+	}
+	tmpl, err := page.master.Clone()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, f := range funcs {
+		tmpl.Funcs(f)
+	}
+	return tmpl, page.entry, nil
+}
+
+// reload 在文件列表或修改时间变化时重新编译
+func (t *HTMLTemplates) reload() error {
+	files, err := t.files()
+	if err != nil {
+		return err
+	}
+	stamp, err := t.stampOf(files)
+	if err != nil {
+		return err
+	}
+	t.mu.RLock()
+	changed := stamp != t.stamp
+	t.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return t.load(files)
+}
+
+type htmlFiles struct {
+	layout   string
+	partials []string
+	pages    []string
+}
+
+func (t *HTMLTemplates) files() (htmlFiles, error) {
+	files := htmlFiles{layout: t.config.Layout}
+	var err error
+	if files.partials, err = t.glob(t.config.Partials); err != nil {
+		return files, err
+	}
+	files.pages, err = t.glob(t.config.Pages)
+	return files, err
+}
+
+func (t *HTMLTemplates) glob(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		var matches []string
+		var err error
+		if t.config.FS == nil {
+			matches, err = filepath.Glob(pattern)
+		} else {
+			matches, err = fs.Glob(t.config.FS, pattern)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("gee: pattern %q matches no files", pattern)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (t *HTMLTemplates) load(files htmlFiles) error {
+	stamp, err := t.stampOf(files)
+	if err != nil {
+		return err
+	}
+	base := template.New("").Funcs(t.config.FuncMap)
+	shared := append([]string(nil), files.partials...)
+	if files.layout != "" {
+		shared = append([]string{files.layout}, shared...)
+	}
+	if len(shared) > 0 {
+		if base, err = t.parse(base, shared); err != nil {
+			return err
+		}
+	}
+	sharedExec, err := base.Clone()
+	if err != nil {
+		return err
+	}
+
+	pages := make(map[string]*htmlPage, len(files.pages))
+	for _, file := range files.pages {
+		master, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		if master, err = t.parse(master, []string{file}); err != nil {
+			return err
+		}
+		exec, err := master.Clone()
+		if err != nil {
+			return err
+		}
+		entry := t.baseName(file)
+		if files.layout != "" {
+			entry = t.baseName(files.layout)
+		}
+		pages[filepath.ToSlash(file)] = &htmlPage{master: master, exec: exec, entry: entry}
+	}
+
+	t.mu.Lock()
+	t.shared = &htmlPage{master: base, exec: sharedExec}
+	t.pages, t.stamp = pages, stamp
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *HTMLTemplates) parse(tmpl *template.Template, files []string) (*template.Template, error) {
+	if t.config.FS == nil {
+		return tmpl.ParseFiles(files...)
+	}
+	return tmpl.ParseFS(t.config.FS, files...)
+}
+
+// baseName 与 ParseFiles/ParseFS 为文件命名的规则一致
+func (t *HTMLTemplates) baseName(file string) string {
+	if t.config.FS == nil {
+		return filepath.Base(file)
+	}
+	return path.Base(file)
+}
+
+func (t *HTMLTemplates) stampOf(files htmlFiles) (string, error) {
+	var b strings.Builder
+	all := append(append([]string{files.layout}, files.partials...), files.pages...)
+	for _, file := range all {
+		if file == "" {
+			continue
+		}
+		var info fs.FileInfo
+		var err error
+		if t.config.FS == nil {
+			info, err = os.Stat(file)
+		} else {
+			info, err = fs.Stat(t.config.FS, file)
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String(), nil
+}
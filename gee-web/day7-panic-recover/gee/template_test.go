@@ -0,0 +1,130 @@
+package gee
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHTMLLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`<title>{{block "title" .}}gee{{end}}</title>{{template "nav" .}}{{block "content" .}}{{end}}`)},
+		"partials/nav.html": {Data: []byte(`{{define "nav"}}<nav>{{greet}}</nav>{{end}}`)},
+		"pages/index.html":  {Data: []byte(`{{define "content"}}<p>{{.}}</p>{{end}}`)},
+		"pages/about.html":  {Data: []byte(`{{define "title"}}about{{end}}{{define "content"}}{{.Missing}}{{end}}`)},
+		"pages/ignored.txt": {Data: []byte(`ignored`)},
+	}
+	r := New()
+	r.Use(Recovery())
+	r.SetFuncMap(template.FuncMap{"greet": func() string { return "hi" }})
+	if err := r.LoadHTML(HTMLConfig{
+		FS:       fsys,
+		Layout:   "layouts/base.html",
+		Partials: []string{"partials/*.html"},
+		Pages:    []string{"pages/*.html"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "pages/index.html", "<gee>")
+	})
+	r.GET("/hello", func(c *Context) {
+		c.HTML(http.StatusOK, "pages/index.html", "gee", template.FuncMap{"greet": func() string { return "hello" }})
+	})
+	r.GET("/about", func(c *Context) {
+		c.HTML(http.StatusOK, "pages/about.html", "gee")
+	})
+
+	cases := map[string]string{
+		"/":      `<title>gee</title><nav>hi</nav><p>&lt;gee&gt;</p>`,
+		"/hello": `<title>gee</title><nav>hello</nav><p>gee</p>`,
+	}
+	for path, want := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Fatalf("%s: unexpected response %d %q", path, w.Code, w.Body.String())
+		}
+	}
+	// per-render funcs should not leak into later renders
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(w.Body.String(), "<nav>hi</nav>") {
+		t.Fatalf("per-render funcs leaked, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "<title>") {
+		t.Fatalf("template errors should become a clean 500, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHTMLErrorWithoutRecovery(t *testing.T) {
+	r := New()
+	if err := r.LoadHTML(HTMLConfig{
+		FS:       fstest.MapFS{"bad.html": {Data: []byte(`<p>{{.Missing}}</p>`)}},
+		Partials: []string{"*.html"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	aborted := false
+	r.Use(func(c *Context) {
+		c.Next()
+		aborted = c.IsAborted()
+	})
+	r.GET("/:name", func(c *Context) {
+		c.HTML(http.StatusOK, c.Param("name"), "gee")
+	})
+	for _, path := range []string{"/bad.html", "/missing.html"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "<p>") || !aborted {
+			t.Fatalf("%s: expect an aborted 500, got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestLoadHTMLGlobError(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "bad.tmpl"), []byte(`{{.Name`), 0o644)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("LoadHTMLGlob should panic on parse errors")
+		}
+	}()
+	New().LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+}
+
+func TestHTMLReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hello.tmpl")
+	os.WriteFile(file, []byte(`v1 {{.}}`), 0o644)
+
+	r := New()
+	if err := r.LoadHTML(HTMLConfig{Partials: []string{filepath.Join(dir, "*.tmpl")}, Debug: true}); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "hello.tmpl", "gee")
+	})
+	get := func() string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Body.String()
+	}
+	if got := get(); got != "v1 gee" {
+		t.Fatalf("unexpected body %q", got)
+	}
+	os.WriteFile(file, []byte(`v2 {{.}}`), 0o644)
+	os.Chtimes(file, time.Now(), time.Now().Add(time.Second))
+	if got := get(); got != "v2 gee" {
+		t.Fatalf("templates should reload in debug mode, got %q", got)
+	}
+}
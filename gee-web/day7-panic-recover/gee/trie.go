@@ -1,107 +1,244 @@
 package gee
 
 import (
-	"errors"
+	"fmt"
+	"sort"
 	"strings"
 )
 
 type Trie struct {
 	root *node
+	// 单条路由最多的参数个数，用于预分配 Context.Params
+	maxParams int
 }
 
 func NewTrie() *Trie {
-	return &Trie{root: &node{childs: make(map[string]*node)}}
+	return &Trie{root: &node{handlers: make(map[string][]HandlerFunc)}}
 }
 
-func (t Trie) Insert(path string, handler HandlerFunc, options *Options) {
-	t.root.insert(strings.Split(path, "/")[1:], handler, options)
+func (t *Trie) Insert(method, path string, handlers []HandlerFunc) {
+	n := 0
+	for _, part := range strings.Split(path, "/") {
+		if part != "" && strings.IndexByte(":*{", part[0]) >= 0 {
+			n++
+		}
+	}
+	if n > t.maxParams {
+		t.maxParams = n
+	}
+	t.root.insert(strings.TrimPrefix(path, "/"), "", method, path, handlers)
 }
 
-func (t Trie) Search(method string, path string) (HandlerFunc, error) {
-	ctx := &Context{Path: path}
-	return t.root.Search(method, strings.Split(path, "/")[1:], ctx)
+// Search 返回匹配路径并注册了 method 的节点，参数追加到 params 中，匹配过程不分配内存。
+// 匹配的节点没有注册 method 时继续回溯，所有节点都不支持时返回 nil
+func (t *Trie) Search(method, path string, params *Params) *node {
+	return t.root.search(strings.TrimPrefix(path, "/"), method, params, nil)
 }
 
-type Options map[string]string
+// Allowed 返回所有匹配 path 的节点上注册的方法和第一个匹配节点的路由模式，方法按字母序排列，
+// 没有节点匹配时返回 nil
+func (t *Trie) Allowed(path string) ([]string, string) {
+	var methods []string
+	var pattern string
+	seen := make(map[string]bool)
+	// 空方法不会匹配任何节点，search 会遍历所有匹配路径的节点
+	t.root.search(strings.TrimPrefix(path, "/"), "", new(Params), func(n *node) {
+		if methods == nil {
+			pattern = n.pattern
+		}
+		for method := range n.handlers {
+			if !seen[method] {
+				seen[method] = true
+				methods = append(methods, method)
+			}
+		}
+	})
+	sort.Strings(methods)
+	return methods, pattern
+}
+
+type nodeKind uint8
+
+// 子节点按 static、param、catchAll 排序，匹配时依次尝试，static 节点之间按字典序，
+// 带约束的 param 节点排在不带约束的前面，彼此之间按注册顺序
+const (
+	static nodeKind = iota
+	param
+	catchAll
+)
 
 type node struct {
-	path    string
-	handler HandlerFunc
-	options *Options
-	childs  map[string]*node
+	path string
+	kind nodeKind
+	// name 是参数名，constraint 是 {name:constraint} 中的约束，accept 为空表示不限制
+	name       string
+	constraint string
+	accept     func(string) bool
+	pattern    string
+	// 同一路径下不同方法的处理函数，key 为 HTTP 方法
+	handlers map[string][]HandlerFunc
+	childs   []*node
 }
 
-func newNode(param string, handler HandlerFunc, options *Options) *node {
-	return &node{path: param, handler: handler, childs: make(map[string]*node), options: options}
+func newNode(part string) *node {
+	n := &node{path: part, handlers: make(map[string][]HandlerFunc)}
+	switch {
+	case strings.HasPrefix(part, ":"):
+		n.kind, n.name = param, part[1:]
+	case strings.HasPrefix(part, "*"):
+		n.kind, n.name = catchAll, part[1:]
+	case strings.HasPrefix(part, "{"):
+		n.kind = param
+		n.name, n.constraint, _ = strings.Cut(part[1:len(part)-1], ":")
+		n.accept = compileConstraint(n.constraint)
+	}
+	return n
 }
 
-func (n *node) insert(part []string, handler HandlerFunc, options *Options) {
-	if len(part) == 0 {
-		n.handler = handler
-		return
+// normalizeSegment 把 {name} 写成等价的 :name，两种写法落到同一个节点
+func normalizeSegment(part string) string {
+	if !strings.HasPrefix(part, "{") {
+		return part
+	}
+	if !strings.HasSuffix(part, "}") || len(part) < 3 {
+		panic(fmt.Sprintf("gee: invalid parameter segment '%s'", part))
+	}
+	name, constraint, ok := strings.Cut(part[1:len(part)-1], ":")
+	if name == "" || ok && constraint == "" {
+		panic(fmt.Sprintf("gee: invalid parameter segment '%s'", part))
 	}
-
-	child, ok := n.childs[part[0]]
 	if !ok {
-		child = newNode(part[0], nil, options)
-		n.childs[part[0]] = child
+		return ":" + name
 	}
-	child.insert(part[1:], handler, options)
-	return
+	return part
 }
 
-func (n *node) Search(method string, path []string, ctx *Context) (HandlerFunc, error) {
-	ctx.Params = make(map[string]string)
-	resultNode, err := n.recurSearch(method, path, ctx)
-	if err != nil {
-		return nil, err
+// insert 的 path 是去掉开头 / 的剩余路径，每一段对应一层节点，walked 是已经走过的前缀
+func (n *node) insert(path, walked, method, pattern string, handlers []HandlerFunc) {
+	part, rest, more := strings.Cut(path, "/")
+	part = normalizeSegment(part)
+	walked += "/" + part
+	child := n.child(part)
+	if child == nil {
+		n.checkConflict(part, walked, pattern)
+		if more && strings.HasPrefix(part, "*") {
+			panic(fmt.Sprintf("gee: catch-all '%s' must be the last segment in path '%s'", part, pattern))
+		}
+		child = newNode(part)
+		n.childs = append(n.childs, child)
+		sort.SliceStable(n.childs, func(i, j int) bool {
+			a, b := n.childs[i], n.childs[j]
+			if a.kind != b.kind {
+				return a.kind < b.kind
+			}
+			if a.kind == param {
+				return a.accept != nil && b.accept == nil
+			}
+			return a.path < b.path
+		})
 	}
-
-	if resultNode.handler == nil {
-		return nil, errors.New("handlers not found")
+	if !more {
+		if _, ok := child.handlers[method]; ok {
+			panic(fmt.Sprintf("gee: %s '%s' conflicts with existing route %s '%s'", method, pattern, method, child.pattern))
+		}
+		child.pattern = pattern
+		child.handlers[method] = handlers
+		return
 	}
+	child.insert(rest, walked, method, pattern, handlers)
+}
 
-	handler := func(c *Context) {
-		c.Params = ctx.Params
-		c.Path = ctx.Path
-		resultNode.handler(c)
+// checkConflict 同一层只允许一个通配节点，参数节点的约束不能相同，否则匹配结果含糊
+func (n *node) checkConflict(part, walked, pattern string) {
+	added := newNode(part)
+	if added.kind == static {
+		return
+	}
+	for _, child := range n.childs {
+		if child.kind == added.kind && child.constraint == added.constraint {
+			existing := strings.TrimSuffix(walked, part) + child.path
+			panic(fmt.Sprintf("gee: '%s' in path '%s' conflicts with existing wildcard '%s' in prefix '%s'",
+				part, pattern, child.path, existing))
+		}
 	}
-
-	return handler, nil
 }
 
-func (n *node) recurSearch(method string, pathList []string, ctx *Context) (*node, error) {
-	if len(pathList) == 0 {
-		if (*n.options)["Method"] != method {
-			return nil, errors.New("405, method is not supported")
+func (n *node) child(part string) *node {
+	for _, child := range n.childs {
+		if child.path == part {
+			return child
 		}
-		return n, nil
 	}
-	if strings.HasPrefix(pathList[0], "*") {
+	return nil
+}
+
+// allowed 返回节点上已注册的方法，按字母序排列
+func (n *node) allowed() []string {
+	methods := make([]string, 0, len(n.handlers))
+	for method := range n.handlers {
+		methods = append(methods, method)
 	}
+	sort.Strings(methods)
+	return methods
+}
 
-	child, ok := n.childs[pathList[0]]
-	// 判断方法
-	// 找不到匹配参数，寻找参数化路径
-	if ok {
-		return child.recurSearch(method, pathList[1:], ctx)
+// walk 按匹配优先级深度遍历所有注册了处理函数的节点
+func (n *node) walk(visit func(n *node)) {
+	if len(n.handlers) > 0 {
+		visit(n)
+	}
+	for _, child := range n.childs {
+		child.walk(visit)
 	}
-	for path, child := range n.childs {
-		// 存在:匹配参数，然后将参数填进去
-		if strings.HasPrefix(path, ":") {
+}
+
+// search 按 static > param > catchAll 的顺序匹配，子树匹配失败或没有注册 method 时回溯。
+// visit 不为空时，每个匹配路径的节点都会传给它
+func (n *node) search(path, method string, params *Params, visit func(n *node)) *node {
+	part, rest, more := strings.Cut(path, "/")
+	for _, child := range n.childs {
+		switch child.kind {
+		case static:
+			if child.path != part {
+				continue
+			}
+			if result := child.match(rest, more, method, params, visit); result != nil {
+				return result
+			}
+		case param:
+			if part == "" || child.accept != nil && !child.accept(part) {
+				continue
+			}
 			// 提取参数，设置到上下文
-			paramName := strings.Split(path, ":")[1]
-			paramValue := pathList[0]
-			ctx.Params[paramName] = paramValue
-			return child.recurSearch(method, pathList[1:], ctx)
-		}
-		// 存在*匹配参数，这时将req对应的后续路径全部塞入参数中
-		if strings.HasPrefix(path, "*") {
-			paramName := strings.Split(path, "*")[1]
-			paramValue := strings.Join(pathList, "/")
-			ctx.Params[paramName] = paramValue
-			return child, nil
+			*params = append(*params, Param{Key: child.name, Value: part})
+			if result := child.match(rest, more, method, params, visit); result != nil {
+				return result
+			}
+			*params = (*params)[:len(*params)-1]
+		case catchAll:
+			// 存在*匹配参数，这时将req对应的后续路径全部塞入参数中
+			*params = append(*params, Param{Key: child.name, Value: path})
+			if result := child.match("", false, method, params, visit); result != nil {
+				return result
+			}
+			*params = (*params)[:len(*params)-1]
 		}
 	}
-	return nil, errors.New("error: node not found")
+	return nil
+}
+
+func (n *node) match(rest string, more bool, method string, params *Params, visit func(n *node)) *node {
+	if more {
+		return n.search(rest, method, params, visit)
+	}
+	if len(n.handlers) == 0 {
+		return nil
+	}
+	if visit != nil {
+		visit(n)
+	}
+	if _, ok := n.handlers[method]; !ok {
+		return nil
+	}
+	return n
 }
@@ -0,0 +1,348 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrFileTooLarge 表示单个文件超过 UploadConfig.MaxFileSize
+	ErrFileTooLarge = errors.New("upload: file too large")
+	// ErrRequestTooLarge 表示请求体超过 UploadConfig.MaxTotalSize
+	ErrRequestTooLarge = errors.New("upload: request body too large")
+	// ErrFileType 表示文件内容的类型不在 UploadConfig.AllowedTypes 中
+	ErrFileType = errors.New("upload: file type not allowed")
+)
+
+// sniffLen 是 http.DetectContentType 最多读取的字节数
+const sniffLen = 512
+
+// UploadConfig 配置文件上传，通过 Engine.SetUploadConfig 设置
+type UploadConfig struct {
+	// MaxMemory 是解析表单时保存在内存中的最大字节数，超出部分写入临时文件，默认 32MB
+	MaxMemory int64
+	// MaxFileSize 是单个文件的大小上限，0 表示不限制
+	MaxFileSize int64
+	// MaxTotalSize 是整个请求体的大小上限，0 表示不限制
+	MaxTotalSize int64
+	// AllowedTypes 是允许的文件类型，如 image/png 或 image/*。
+	// 类型根据文件内容嗅探，不信任客户端声明的 Content-Type，为空时不限制
+	AllowedTypes []string
+}
+
+// SetUploadConfig 设置 MultipartForm、FormFile 和 StreamUpload 使用的限制
+func (engine *Engine) SetUploadConfig(config UploadConfig) {
+	if config.MaxMemory <= 0 {
+		config.MaxMemory = defaultMultipartMemory
+	}
+	engine.upload = config
+}
+
+func (c *Context) uploadConfig() UploadConfig {
+	if c.engine == nil || c.engine.upload.MaxMemory == 0 {
+		return UploadConfig{MaxMemory: defaultMultipartMemory}
+	}
+	return c.engine.upload
+}
+
+// MultipartForm 解析 multipart 表单并按 UploadConfig 检查。文件在解析过程中逐个检查，
+// 超过大小或类型不符时立即停止，不会先把整个文件写入临时文件。
+// 已经由其他方式（例如直接调用 Req.ParseMultipartForm）解析的表单会在返回前补做检查。
+// 检查失败后请求体已经读过，之后的调用都返回同一个错误，不是 multipart 请求时同样如此
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if c.formErr != nil {
+		return nil, c.formErr
+	}
+	form, err := c.multipartForm(c.uploadConfig())
+	if err != nil {
+		// Req.MultipartReader 失败后会在 Req.MultipartForm 中留下一个空表单，不能再当作已解析的表单检查
+		c.formErr = err
+	}
+	return form, err
+}
+
+func (c *Context) multipartForm(config UploadConfig) (*multipart.Form, error) {
+	if form := c.Req.MultipartForm; form != nil {
+		if form == c.form {
+			return form, nil
+		}
+		if err := checkForm(form, config); err != nil {
+			form.RemoveAll()
+			c.Req.MultipartForm = nil
+			return nil, err
+		}
+		c.form = form
+		return form, nil
+	}
+	if config.MaxTotalSize > 0 && c.Req.Body != nil {
+		c.Req.Body = http.MaxBytesReader(c.Writer, c.Req.Body, config.MaxTotalSize)
+	}
+	reader, err := c.Req.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	// 检查后的各部分重新编码，经过管道交给 ReadForm 处理内存和临时文件
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(copyParts(reader, writer, config))
+	}()
+	form, err := multipart.NewReader(pr, writer.Boundary()).ReadForm(config.MaxMemory)
+	pr.Close()
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	// 与 Req.ParseMultipartForm 一样填充 Form 和 PostForm
+	if err := c.Req.ParseForm(); err != nil {
+		form.RemoveAll()
+		return nil, err
+	}
+	for k, v := range form.Value {
+		c.Req.Form[k] = append(c.Req.Form[k], v...)
+		c.Req.PostForm[k] = append(c.Req.PostForm[k], v...)
+	}
+	c.Req.MultipartForm, c.form = form, form
+	return form, nil
+}
+
+func copyParts(reader *multipart.Reader, writer *multipart.Writer, config UploadConfig) error {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return writer.Close()
+		}
+		if err != nil {
+			return err
+		}
+		p, err := newUploadPart(part, config)
+		if err != nil {
+			return err
+		}
+		w, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, p); err != nil {
+			return err
+		}
+	}
+}
+
+// parseForm 解析查询参数和请求体中的表单，multipart 表单经过 MultipartForm 的检查
+func (c *Context) parseForm() error {
+	if _, err := c.MultipartForm(); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	return c.Req.ParseForm()
+}
+
+// FormFile 返回表单中名为 name 的第一个文件
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File[name]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return files[0], nil
+}
+
+// SaveUploadedFile 把上传的文件保存到 dst，目录不存在时自动创建
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = saveFile(src, dst)
+	return err
+}
+
+// checkForm 检查已经解析好的表单，总大小按文件和字段的大小估算
+func checkForm(form *multipart.Form, config UploadConfig) error {
+	var total int64
+	for _, values := range form.Value {
+		for _, v := range values {
+			total += int64(len(v))
+		}
+	}
+	for _, files := range form.File {
+		for _, file := range files {
+			if err := checkFile(file, config); err != nil {
+				return err
+			}
+			total += file.Size
+		}
+	}
+	if config.MaxTotalSize > 0 && total > config.MaxTotalSize {
+		return fmt.Errorf("%w: limit is %d bytes", ErrRequestTooLarge, config.MaxTotalSize)
+	}
+	return nil
+}
+
+func checkFile(file *multipart.FileHeader, config UploadConfig) error {
+	if config.MaxFileSize > 0 && file.Size > config.MaxFileSize {
+		return fmt.Errorf("%w: %s", ErrFileTooLarge, file.Filename)
+	}
+	if len(config.AllowedTypes) == 0 {
+		return nil
+	}
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	return checkType(file.Filename, http.DetectContentType(head[:n]), config.AllowedTypes)
+}
+
+func checkType(filename, contentType string, allowed []string) error {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return nil
+			}
+		} else if mediaType == pattern {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is %s", ErrFileType, filename, mediaType)
+}
+
+// uploadError 把请求体超限的错误换成 ErrRequestTooLarge
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: limit is %d bytes", ErrRequestTooLarge, maxBytesErr.Limit)
+	}
+	return err
+}
+
+// UploadPart 是流式读取 multipart 表单时的一个部分，读取时按 UploadConfig 检查大小
+type UploadPart struct {
+	FormName string
+	// FileName 为空表示普通表单字段
+	FileName string
+	// ContentType 是文件部分根据内容嗅探出的类型
+	ContentType string
+	Header      textproto.MIMEHeader
+	r           io.Reader
+}
+
+func (p *UploadPart) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+// SaveTo 把这一部分写入 dst，出错时删除写了一半的文件
+func (p *UploadPart) SaveTo(dst string) (int64, error) {
+	return saveFile(p, dst)
+}
+
+// StreamUpload 逐个读取 multipart 表单的各部分并交给 handle，不会缓冲整个请求体。
+// handle 返回后该部分剩余的数据被丢弃；handle 返回错误时停止读取并返回该错误。
+// 文件部分在交给 handle 之前已经完成类型检查
+func (c *Context) StreamUpload(handle func(part *UploadPart) error) error {
+	config := c.uploadConfig()
+	if config.MaxTotalSize > 0 && c.Req.Body != nil {
+		c.Req.Body = http.MaxBytesReader(c.Writer, c.Req.Body, config.MaxTotalSize)
+	}
+	reader, err := c.Req.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return uploadError(err)
+		}
+		p, err := newUploadPart(part, config)
+		if err != nil {
+			return err
+		}
+		err = handle(p)
+		part.Close()
+		if err != nil {
+			return uploadError(err)
+		}
+	}
+}
+
+// newUploadPart 包装 part：文件部分先嗅探类型并检查，读取时限制大小
+func newUploadPart(part *multipart.Part, config UploadConfig) (*UploadPart, error) {
+	p := &UploadPart{FormName: part.FormName(), FileName: part.FileName(), Header: part.Header, r: part}
+	if p.FileName == "" {
+		return p, nil
+	}
+	buffered := bufio.NewReaderSize(part, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, uploadError(err)
+	}
+	p.ContentType = http.DetectContentType(head)
+	if len(config.AllowedTypes) > 0 {
+		if err := checkType(p.FileName, p.ContentType, config.AllowedTypes); err != nil {
+			return nil, err
+		}
+	}
+	p.r = buffered
+	if config.MaxFileSize > 0 {
+		p.r = &limitedReader{r: buffered, n: config.MaxFileSize, name: p.FileName}
+	}
+	return p, nil
+}
+
+// limitedReader 读到超过 n 字节时返回 ErrFileTooLarge，而不是像 io.LimitReader 那样静默截断
+type limitedReader struct {
+	r    io.Reader
+	n    int64
+	name string
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if int64(len(b)) > l.n+1 {
+		b = b[:l.n+1]
+	}
+	n, err := l.r.Read(b)
+	if int64(n) > l.n {
+		return int(l.n), fmt.Errorf("%w: %s", ErrFileTooLarge, l.name)
+	}
+	l.n -= int64(n)
+	return n, err
+}
+
+func saveFile(src io.Reader, dst string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return 0, err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, src)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return n, err
+	}
+	return n, nil
+}
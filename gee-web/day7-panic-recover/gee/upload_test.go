@@ -0,0 +1,195 @@
+package gee
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n0000IHDR")
+
+func newUploadContext(t *testing.T, config UploadConfig, files map[string][]byte) *Context {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("title", "gee")
+	for name, content := range files {
+		fw, err := w.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(content)
+	}
+	w.Close()
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	engine := New()
+	engine.SetUploadConfig(config)
+	c := NewContext(httptest.NewRecorder(), req)
+	c.engine = engine
+	return c
+}
+
+func TestFormFile(t *testing.T) {
+	c := newUploadContext(t, UploadConfig{}, map[string][]byte{"avatar": pngHeader})
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "a", "avatar.png")
+	if err := c.SaveUploadedFile(file, dst); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(dst); !bytes.Equal(b, pngHeader) {
+		t.Fatalf("saved %q", b)
+	}
+	if c.PostForm("title") != "gee" {
+		t.Fatal("form value lost")
+	}
+	if _, err := c.FormFile("missing"); err == nil {
+		t.Fatal("expect missing file error")
+	}
+}
+
+func TestFormFileNotMultipart(t *testing.T) {
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("title=gee"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := NewContext(httptest.NewRecorder(), req)
+	for i := 0; i < 2; i++ {
+		if _, err := c.FormFile("avatar"); !errors.Is(err, http.ErrNotMultipart) {
+			t.Fatalf("call %d: expect ErrNotMultipart, got %v", i, err)
+		}
+	}
+	if c.PostForm("title") != "gee" {
+		t.Fatal("urlencoded form should still be parsed")
+	}
+}
+
+func TestMultipartFormLimits(t *testing.T) {
+	tests := []struct {
+		config UploadConfig
+		want   error
+	}{
+		{UploadConfig{MaxFileSize: 4}, ErrFileTooLarge},
+		{UploadConfig{MaxTotalSize: 64}, ErrRequestTooLarge},
+		{UploadConfig{AllowedTypes: []string{"image/jpeg"}}, ErrFileType},
+		{UploadConfig{AllowedTypes: []string{"image/*"}, MaxFileSize: 1024}, nil},
+	}
+	for _, tt := range tests {
+		c := newUploadContext(t, tt.config, map[string][]byte{"avatar": pngHeader})
+		_, err := c.MultipartForm()
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%+v: expect %v, got %v", tt.config, tt.want, err)
+		}
+	}
+}
+
+func TestMultipartFormParsedElsewhere(t *testing.T) {
+	config := UploadConfig{AllowedTypes: []string{"image/png"}}
+	parsers := map[string]func(c *Context){
+		"PostForm": func(c *Context) { c.PostForm("title") },
+		"BindForm": func(c *Context) {
+			var form struct {
+				Title string `form:"title"`
+			}
+			c.BindForm(&form)
+		},
+		"Req.ParseMultipartForm": func(c *Context) { c.Req.ParseMultipartForm(1 << 20) },
+	}
+	for name, parse := range parsers {
+		c := newUploadContext(t, config, map[string][]byte{"doc": []byte("plain text")})
+		parse(c)
+		if _, err := c.FormFile("doc"); !errors.Is(err, ErrFileType) {
+			t.Fatalf("%s first: expect ErrFileType, got %v", name, err)
+		}
+	}
+
+	c := newUploadContext(t, config, map[string][]byte{"avatar": pngHeader})
+	if c.PostForm("title") != "gee" {
+		t.Fatal("PostForm should read multipart values")
+	}
+	if _, err := c.FormFile("avatar"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countingReader 记录读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.n += n
+	return n, err
+}
+
+func TestMultipartFormStopsEarly(t *testing.T) {
+	size := 8 << 20
+	c := newUploadContext(t, UploadConfig{MaxMemory: 1, MaxFileSize: 1024},
+		map[string][]byte{"big": bytes.Repeat([]byte("x"), size)})
+	body := &countingReader{r: c.Req.Body}
+	c.Req.Body = io.NopCloser(body)
+	if _, err := c.MultipartForm(); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expect ErrFileTooLarge, got %v", err)
+	}
+	if body.n >= size/2 {
+		t.Fatalf("oversized file should be rejected while reading, read %d of %d bytes", body.n, size)
+	}
+	if _, err := c.FormFile("big"); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("later calls should return the same error, got %v", err)
+	}
+}
+
+func TestStreamUpload(t *testing.T) {
+	dir := t.TempDir()
+	c := newUploadContext(t, UploadConfig{AllowedTypes: []string{"text/plain"}}, map[string][]byte{"doc": []byte("hello gee")})
+	var fields []string
+	err := c.StreamUpload(func(part *UploadPart) error {
+		if part.FileName == "" {
+			b, _ := io.ReadAll(part)
+			fields = append(fields, part.FormName+"="+string(b))
+			return nil
+		}
+		if !strings.HasPrefix(part.ContentType, "text/plain") {
+			t.Fatalf("unexpected content type %s", part.ContentType)
+		}
+		_, err := part.SaveTo(filepath.Join(dir, part.FileName))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0] != "title=gee" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "doc.bin")); string(b) != "hello gee" {
+		t.Fatalf("saved %q", b)
+	}
+}
+
+func TestStreamUploadFileTooLarge(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "big")
+	c := newUploadContext(t, UploadConfig{MaxFileSize: 8}, map[string][]byte{"big": bytes.Repeat([]byte("x"), 1024)})
+	err := c.StreamUpload(func(part *UploadPart) error {
+		if part.FileName == "" {
+			return nil
+		}
+		_, err := part.SaveTo(dst)
+		return err
+	})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expect ErrFileTooLarge, got %v", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatal("partial file should be removed")
+	}
+}
@@ -0,0 +1,115 @@
+package gee
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Route 是注册方法的返回值，用于给路由补充信息
+type Route struct {
+	engine  *Engine
+	host    *hostRouter
+	methods []string
+	pattern string
+	doc     *routeDoc
+}
+
+// Name 给路由命名，之后可以用 Engine.URL 生成它的地址，名字重复时 panic
+func (r *Route) Name(name string) *Route {
+	if existing, ok := r.engine.routeNames[name]; ok && existing != r.pattern {
+		panic(fmt.Sprintf("gee: route name '%s' is already used by '%s'", name, existing))
+	}
+	if r.engine.routeNames == nil {
+		r.engine.routeNames = make(map[string]string)
+	}
+	r.engine.routeNames[name] = r.pattern
+	return r
+}
+
+// URL 根据命名路由生成地址，pairs 是交替出现的参数名和值，例如
+// URL("user.show", "id", 42)。不在路由模式中的参数作为查询参数追加，可选段缺少参数时省略
+func (engine *Engine) URL(name string, pairs ...any) (string, error) {
+	pattern, ok := engine.routeNames[name]
+	if !ok {
+		return "", fmt.Errorf("gee: route '%s' is not defined", name)
+	}
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("gee: URL for '%s' needs key-value pairs", name)
+	}
+	values := make(map[string]string, len(pairs)/2)
+	var keys []string
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return "", fmt.Errorf("gee: URL for '%s': parameter name %v is not a string", name, pairs[i])
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = fmt.Sprint(pairs[i+1])
+	}
+
+	var b strings.Builder
+	for _, part := range strings.Split(strings.TrimPrefix(pattern, "/"), "/") {
+		seg, ok, err := buildSegment(part, values)
+		if err != nil {
+			return "", fmt.Errorf("gee: URL for '%s': %w", name, err)
+		}
+		if !ok {
+			// 可选段缺少参数，后面的可选段也一并省略
+			break
+		}
+		b.WriteString("/")
+		b.WriteString(seg)
+	}
+	if b.Len() == 0 {
+		b.WriteString("/")
+	}
+
+	query := url.Values{}
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	if len(query) > 0 {
+		b.WriteString("?")
+		b.WriteString(query.Encode())
+	}
+	return b.String(), nil
+}
+
+// buildSegment 生成一段路径并从 values 中删除用掉的参数，可选段缺少参数时 ok 为 false
+func buildSegment(part string, values map[string]string) (seg string, ok bool, err error) {
+	var name, constraint string
+	part, optional := optionalSegment(part)
+	switch {
+	case strings.HasPrefix(part, ":"):
+		name = part[1:]
+	case strings.HasPrefix(part, "*"):
+		value := values[part[1:]]
+		delete(values, part[1:])
+		segs := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for i, s := range segs {
+			segs[i] = url.PathEscape(s)
+		}
+		return strings.Join(segs, "/"), true, nil
+	case strings.HasPrefix(part, "{"):
+		name, constraint, _ = strings.Cut(part[1:len(part)-1], ":")
+	default:
+		return part, true, nil
+	}
+	value := values[name]
+	if value == "" {
+		if optional {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("missing parameter '%s'", name)
+	}
+	if accept := compileConstraint(constraint); accept != nil && !accept(value) {
+		return "", false, fmt.Errorf("parameter '%s' does not satisfy constraint '%s'", name, constraint)
+	}
+	delete(values, name)
+	return url.PathEscape(value), true, nil
+}
@@ -0,0 +1,61 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestURL(t *testing.T) {
+	r := New()
+	api := r.Group("/api")
+	api.GET("/user/:id", func(c *Context) {}).Name("user.show")
+	api.GET("/file/{id:int}/*path", func(c *Context) {}).Name("file")
+	r.GET("/archive/{year?:int}/{month?}", func(c *Context) {}).Name("archive")
+	r.GET("/proto/{p:https?}", func(c *Context) {}).Name("proto")
+	r.GET("/", func(c *Context) {}).Name("home")
+
+	cases := []struct {
+		name  string
+		pairs []any
+		want  string
+	}{
+		{"user.show", []any{"id", 42}, "/api/user/42"},
+		{"user.show", []any{"id", "a b", "tab", "posts", "page", 2}, "/api/user/a%20b?page=2&tab=posts"},
+		{"file", []any{"id", 7, "path", "docs/read me.md"}, "/api/file/7/docs/read%20me.md"},
+		{"archive", []any{"year", 2020}, "/archive/2020"},
+		{"archive", nil, "/archive"},
+		{"proto", []any{"p", "http"}, "/proto/http"},
+		{"home", nil, "/"},
+	}
+	for _, tc := range cases {
+		got, err := r.URL(tc.name, tc.pairs...)
+		if err != nil || got != tc.want {
+			t.Fatalf("URL(%s, %v) should be %s, got %s %v", tc.name, tc.pairs, tc.want, got, err)
+		}
+	}
+	for _, pairs := range [][]any{{"user.show"}, {"user.show", "id"}, {"file", "id", "x", "path", "a"}, {"proto"}, {"missing"}} {
+		if _, err := r.URL(pairs[0].(string), pairs[1:]...); err == nil {
+			t.Fatalf("URL%v should fail", pairs)
+		}
+	}
+}
+
+func TestURLTemplateFunc(t *testing.T) {
+	r := New()
+	r.GET("/user/:id", func(c *Context) {
+		c.HTML(http.StatusOK, "link.html", 42)
+	}).Name("user.show")
+	if err := r.LoadHTML(HTMLConfig{
+		FS:       fstest.MapFS{"link.html": {Data: []byte(`<a href="{{url "user.show" "id" .}}">me</a>`)}},
+		Partials: []string{"*.html"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/1", nil))
+	if want := `<a href="/user/42">me</a>`; w.Body.String() != want {
+		t.Fatalf("url func should build the link, got %q", w.Body.String())
+	}
+}
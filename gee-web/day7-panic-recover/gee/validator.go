@@ -0,0 +1,171 @@
+package gee
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError 描述单个字段未通过的校验规则，可直接序列化成 JSON 返回给客户端
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors 是一次绑定中所有未通过校验的字段
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validate 按 binding 标签校验结构体，字段名取自 tagKey 对应的标签
+func validate(obj any, tagKey string) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	validateStruct(v, "", tagKey, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix, tagKey string, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field, tagKey)
+		if name == "-" {
+			continue
+		}
+		name = prefix + name
+		if rules := field.Tag.Get("binding"); rules != "" {
+			validateField(fv, name, rules, errs)
+		}
+		inner := reflect.Indirect(fv)
+		if inner.Kind() == reflect.Struct && inner.Type() != timeType {
+			validateStruct(inner, name+".", tagKey, errs)
+		}
+	}
+}
+
+func fieldName(field reflect.StructField, tagKey string) string {
+	name := field.Tag.Get(tagKey)
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func validateField(v reflect.Value, name, rules string, errs *ValidationErrors) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.Value{}
+		} else {
+			v = v.Elem()
+		}
+	}
+	zero := !v.IsValid() || v.IsZero() || (isLengthKind(v) && v.Len() == 0)
+	for _, rule := range strings.Split(rules, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if rule == "required" {
+			if zero {
+				*errs = append(*errs, FieldError{Field: name, Rule: rule,
+					Message: fmt.Sprintf("%s is required", name)})
+				return
+			}
+			continue
+		}
+		// 非必填字段为空时跳过其余规则
+		if zero {
+			return
+		}
+		if msg := checkRule(v, rule, param); msg != "" {
+			*errs = append(*errs, FieldError{Field: name, Rule: rule, Param: param,
+				Message: fmt.Sprintf("%s %s", name, msg)})
+		}
+	}
+}
+
+func isLengthKind(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// checkRule 校验通过返回空字符串，否则返回错误描述
+func checkRule(v reflect.Value, rule, param string) string {
+	switch rule {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("gee: invalid binding rule %s=%s", rule, param))
+		}
+		n, unit := measure(v)
+		switch {
+		case rule == "min" && n < limit:
+			return fmt.Sprintf("must be at least %s%s", param, unit)
+		case rule == "max" && n > limit:
+			return fmt.Sprintf("must be at most %s%s", param, unit)
+		case rule == "len" && n != limit:
+			return fmt.Sprintf("must be exactly %s%s", param, unit)
+		}
+	case "email":
+		s := fmt.Sprint(v.Interface())
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be a valid email address"
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", param)
+	default:
+		panic(fmt.Sprintf("gee: unknown binding rule %q", rule))
+	}
+	return ""
+}
+
+// measure 数值类型比较值本身，字符串和集合比较长度
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), " items"
+	}
+	panic(fmt.Sprintf("gee: min/max/len not supported on %s", v.Type()))
+}
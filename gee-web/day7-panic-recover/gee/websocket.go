@@ -0,0 +1,30 @@
+package gee
+
+import (
+	"gee/websocket"
+)
+
+// Upgrade 把当前请求升级为 WebSocket 连接，之前的中间件（鉴权、日志等）已经执行。
+// 成功后 Writer.Status() 为 101，握手失败时错误响应已经写出。
+// 默认拒绝跨站的 Origin，需要跨站访问时用 upgrader 的 CheckOrigin 放行
+func (c *Context) Upgrade(upgrader ...websocket.Upgrader) (*websocket.Conn, error) {
+	var u websocket.Upgrader
+	if len(upgrader) > 0 {
+		u = upgrader[0]
+	}
+	return u.Upgrade(c.Writer, c.Req, nil)
+}
+
+// WebSocket 把 handler 包装成处理函数，握手失败时中止调用链。
+// handler 返回后连接会被关闭，不能在 handler 之外持有 Context
+func WebSocket(handler func(c *Context, conn *websocket.Conn), upgrader ...websocket.Upgrader) HandlerFunc {
+	return func(c *Context) {
+		conn, err := c.Upgrade(upgrader...)
+		if err != nil {
+			c.Abort()
+			return
+		}
+		defer conn.Close()
+		handler(c, conn)
+	}
+}
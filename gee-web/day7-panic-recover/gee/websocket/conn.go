@@ -0,0 +1,298 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 消息类型，与帧的 opcode 一致
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// 关闭状态码，见 RFC 6455 7.4.1
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	CloseMessageTooBig    = 1009
+)
+
+const (
+	continuationFrame = 0
+	finalBit          = 0x80
+	rsvBits           = 0x70
+	opcodeMask        = 0x0f
+	maskBit           = 0x80
+	// 控制帧的负载不能超过 125 字节
+	maxControlPayload = 125
+	// DefaultReadLimit 是单条消息默认的最大字节数
+	DefaultReadLimit = 32 << 20
+)
+
+// CloseError 表示收到了对端的关闭帧
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+var ErrCloseSent = errors.New("websocket: close sent")
+
+// Conn 是一条 WebSocket 连接。读和写各自只能有一个 goroutine，
+// 写方法内部加锁，读取时收到的 ping 会自动回复 pong
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	isServer bool
+	// ReadLimit 是单条消息的最大字节数，超出时以 1009 关闭连接
+	ReadLimit int64
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool) *Conn {
+	return &Conn{conn: conn, br: br, isServer: isServer, ReadLimit: DefaultReadLimit}
+}
+
+// NewClientConn 包装已经完成握手的客户端连接，客户端发送的帧需要加掩码
+func NewClientConn(conn net.Conn, br *bufio.Reader) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return newConn(conn, br, false)
+}
+
+func (c *Conn) LocalAddr() net.Addr  { return c.conn.LocalAddr() }
+func (c *Conn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+func (c *Conn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *Conn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
+
+// Close 直接关闭底层连接，不发送关闭帧
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+type frame struct {
+	fin     bool
+	opcode  int
+	payload []byte
+}
+
+func (c *Conn) readFrame(limit int64) (*frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return nil, err
+	}
+	f := &frame{fin: head[0]&finalBit != 0, opcode: int(head[0] & opcodeMask)}
+	if head[0]&rsvBits != 0 {
+		return nil, c.protocolError("reserved bits are set")
+	}
+	masked := head[1]&maskBit != 0
+	if masked != c.isServer {
+		return nil, c.protocolError("incorrect mask flag")
+	}
+
+	length := int64(head[1] &^ maskBit)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	// RFC 6455 5.2 要求 64 位长度的最高位为 0，否则转换后是负数
+	if length < 0 {
+		return nil, c.protocolError("invalid payload length")
+	}
+
+	if f.opcode >= CloseMessage {
+		if !f.fin || length > maxControlPayload {
+			return nil, c.protocolError("invalid control frame")
+		}
+	} else if length > limit {
+		c.WriteClose(CloseMessageTooBig, "message too big")
+		return nil, &CloseError{Code: CloseMessageTooBig, Text: "message too big"}
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return nil, err
+		}
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return nil, err
+	}
+	if masked {
+		maskBytes(key, f.payload)
+	}
+	return f, nil
+}
+
+// ReadMessage 读取一条完整的数据消息，分片会被拼接，控制帧在内部处理。
+// 收到关闭帧时回复关闭帧并返回 *CloseError
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	for {
+		f, err := c.readFrame(c.ReadLimit - int64(len(p)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch f.opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, f.payload); err != nil && err != ErrCloseSent {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.protocolError("expected continuation frame")
+			}
+			messageType = f.opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.protocolError("unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.protocolError(fmt.Sprintf("unknown opcode %d", f.opcode))
+		}
+		p = append(p, f.payload...)
+		if !f.fin {
+			continue
+		}
+		if messageType == TextMessage && !utf8.Valid(p) {
+			c.WriteClose(CloseInvalidPayload, "invalid utf8 payload")
+			return 0, nil, &CloseError{Code: CloseInvalidPayload, Text: "invalid utf8 payload"}
+		}
+		return messageType, p, nil
+	}
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+	}
+	// 回复相同的状态码完成关闭握手
+	code := closeErr.Code
+	if code == CloseNoStatusReceived {
+		code = CloseNormalClosure
+	}
+	c.WriteClose(code, "")
+	return closeErr
+}
+
+func (c *Conn) protocolError(message string) error {
+	c.WriteClose(CloseProtocolError, message)
+	return &CloseError{Code: CloseProtocolError, Text: message}
+}
+
+// WriteMessage 发送一条不分片的消息，可以是数据消息或 ping/pong
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage, PingMessage, PongMessage:
+	case CloseMessage:
+		return errors.New("websocket: use WriteClose to send close message")
+	default:
+		return fmt.Errorf("websocket: unknown message type %d", messageType)
+	}
+	if messageType >= CloseMessage && len(data) > maxControlPayload {
+		return errors.New("websocket: control frame too long")
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteClose 发送关闭帧，之后不能再写入
+func (c *Conn) WriteClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+	err := c.writeFrame(CloseMessage, payload)
+	if err == nil {
+		c.writeMu.Lock()
+		c.closeSent = true
+		c.writeMu.Unlock()
+	}
+	return err
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, finalBit|byte(opcode))
+	var maskFlag byte
+	if !c.isServer {
+		maskFlag = maskBit
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskFlag|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskFlag|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskFlag|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if c.isServer {
+		buf = append(buf, payload...)
+	} else {
+		// 客户端发送的帧必须使用随机掩码
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		buf = append(buf, key[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		maskBytes(key, buf[start:])
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
@@ -0,0 +1,28 @@
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestReadNegativeLength(t *testing.T) {
+	for _, opcode := range []byte{PingMessage, BinaryMessage} {
+		server, client := net.Pipe()
+		go io.Copy(io.Discard, client)
+		go func() {
+			// 带掩码、长度标记为 127、64 位长度最高位为 1 的帧
+			client.Write([]byte{0x80 | opcode, 0x80 | 127, 0x80, 0, 0, 0, 0, 0, 0, 1, 1, 2, 3, 4})
+		}()
+		conn := newConn(server, bufio.NewReader(server), true)
+		_, _, err := conn.ReadMessage()
+		var closeErr *CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != CloseProtocolError {
+			t.Fatalf("opcode %d: expect protocol error, got %v", opcode, err)
+		}
+		server.Close()
+		client.Close()
+	}
+}
@@ -0,0 +1,125 @@
+// Package websocket 基于标准库实现 RFC 6455 握手和数据帧
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// acceptGUID 是 RFC 6455 规定的固定 GUID，用于计算 Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError 表示握手失败，Upgrade 已经把 Status 写入响应
+type HandshakeError struct {
+	Status  int
+	Message string
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket: " + e.Message
+}
+
+// Upgrader 配置握手
+type Upgrader struct {
+	// CheckOrigin 返回 false 时以 403 拒绝握手。默认只接受没有 Origin 或 Origin 与 Host 相同的请求，
+	// 浏览器会为跨站页面发起的连接带上 Cookie，不检查来源会被跨站劫持
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Upgrade 使用默认的 Upgrader 完成握手
+func Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	var u Upgrader
+	return u.Upgrade(w, r, header)
+}
+
+// Upgrade 校验握手请求并通过 http.Hijacker 接管连接，header 会附加到 101 响应中。
+// 失败时已经写好错误响应，调用方只需返回
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	fail := func(status int, message string) (*Conn, error) {
+		if status == http.StatusUpgradeRequired {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+		}
+		http.Error(w, http.StatusText(status), status)
+		return nil, &HandshakeError{Status: status, Message: message}
+	}
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") {
+		return fail(http.StatusBadRequest, "'upgrade' token not found in 'Connection' header")
+	}
+	if !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "'websocket' token not found in 'Upgrade' header")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusUpgradeRequired, "unsupported version")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return fail(http.StatusForbidden, "request origin is not allowed")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return fail(http.StatusBadRequest, "'Sec-WebSocket-Key' header is missing or invalid")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return fail(http.StatusInternalServerError, "response does not implement http.Hijacker")
+	}
+
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n")
+	for k, vs := range header {
+		for _, v := range vs {
+			b.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	// 复用 Hijack 返回的 Reader，其中可能已经缓冲了客户端的数据
+	return newConn(netConn, rw.Reader, true), nil
+}
+
+// AcceptKey 根据客户端的 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func AcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// sameOrigin 判断 Origin 的主机是否与 Host 相同，没有 Origin 的请求不是浏览器发起的，直接接受
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContains 判断逗号分隔的头部值中是否包含 token，不区分大小写
+func headerContains(header http.Header, name, token string) bool {
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+package websocket
+
+import "testing"
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455 1.3 中的示例
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %s", got)
+	}
+}
@@ -0,0 +1,143 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/websocket"
+)
+
+func dialWebSocket(t *testing.T, addr, path string, header ...string) (*websocket.Conn, *http.Response) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	req, _ := http.NewRequest("GET", "http://"+addr+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, resp
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocket.AcceptKey(key) {
+		t.Fatal("unexpected Sec-WebSocket-Accept")
+	}
+	return websocket.NewClientConn(conn, br), resp
+}
+
+func TestWebSocket(t *testing.T) {
+	r := New()
+	// 鉴权中间件在升级之前执行
+	r.Use(func(c *Context) {
+		if c.Query("token") == "" {
+			c.Fail(http.StatusUnauthorized, "token required")
+			return
+		}
+		c.Next()
+	})
+	r.GET("/echo", WebSocket(func(c *Context, conn *websocket.Conn) {
+		for {
+			mt, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(mt, append([]byte(c.Query("prefix")), p...))
+		}
+	}))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	conn, _ := dialWebSocket(t, addr, "/echo?token=t&prefix=gee:")
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.PingMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"hello", strings.Repeat("x", 70000)} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		mt, p, err := conn.ReadMessage()
+		if err != nil || mt != websocket.TextMessage || string(p) != "gee:"+msg {
+			t.Fatalf("unexpected echo %d %.20q %v", mt, p, err)
+		}
+	}
+
+	conn.WriteClose(websocket.CloseNormalClosure, "bye")
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure {
+		t.Fatalf("server should echo close frame, got %v", err)
+	}
+
+	if _, resp := dialWebSocket(t, addr, "/echo"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("upgrade should be rejected by middleware, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebSocketHandshakeFail(t *testing.T) {
+	r := New()
+	r.GET("/echo", WebSocket(func(c *Context, conn *websocket.Conn) {
+		t.Fatal("handler should not run when handshake fails")
+	}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/echo", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("plain GET should be rejected with 400, got %d", w.Code)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	r := New()
+	handler := func(c *Context, conn *websocket.Conn) {}
+	r.GET("/ws", WebSocket(handler))
+	r.GET("/open", WebSocket(handler, websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == "https://app.example" },
+	}))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	tests := []struct {
+		path, origin string
+		code         int
+	}{
+		{"/ws", "", http.StatusSwitchingProtocols},
+		{"/ws", "http://" + addr, http.StatusSwitchingProtocols},
+		{"/ws", "https://evil.example", http.StatusForbidden},
+		{"/ws", "null", http.StatusForbidden},
+		{"/open", "https://app.example", http.StatusSwitchingProtocols},
+		{"/open", "http://" + addr, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		var header []string
+		if tt.origin != "" {
+			header = []string{"Origin", tt.origin}
+		}
+		conn, resp := dialWebSocket(t, addr, tt.path, header...)
+		if conn != nil {
+			conn.Close()
+		}
+		if resp.StatusCode != tt.code {
+			t.Fatalf("%s from %q: expect %d, got %d", tt.path, tt.origin, tt.code, resp.StatusCode)
+		}
+	}
+}
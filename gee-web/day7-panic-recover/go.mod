@@ -1,7 +1,12 @@
 module example
 
-go 1.13
+go 1.21
 
-require gee v0.0.0
+require (
+	gee v0.0.0
+	geecache v0.0.0
+)
 
 replace gee => ./gee
+
+replace geecache => ../../gee-cache/day6-single-flight/geecache